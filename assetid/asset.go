@@ -0,0 +1,35 @@
+package assetid
+
+// Asset describes a fingerprinted asset. It is the type of the variables
+// generated by `assetid gen-go`, so removing an asset breaks the build of any
+// code that still refers to it.
+type Asset struct {
+	// Name is the original filename, e.g. img/logo.png
+	Name string
+	// URL is the fingerprinted path, e.g. /dist/img/logo-a1b2c3d4e5f67890.png
+	URL string
+	// Integrity is the Subresource Integrity value, if known
+	Integrity string
+	// Size is the size of the fingerprinted file in bytes
+	Size int64
+}
+
+// String returns the fingerprinted URL, so an Asset can be used directly in templates
+func (a Asset) String() string {
+	return a.URL
+}
+
+// Asset describes the asset with the given original filename
+func (l *Loader) Asset(name string) (Asset, bool) {
+	info, err := l.Stat(name)
+	if err != nil {
+		return Asset{}, false
+	}
+
+	return Asset{
+		Name:      name,
+		URL:       l.Path(name),
+		Integrity: l.Integrity(name),
+		Size:      info.Size(),
+	}, true
+}
@@ -1,43 +1,298 @@
 package assetid
 
 import (
+	"crypto/sha512"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"io/fs"
+	"iter"
+	"path"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 )
 
+// urlPrefix is the URL path under which fingerprinted assets are served
+const urlPrefix = "/dist"
+
+// ManifestFile is the name of the manifest written to the root of the output directory
+const ManifestFile = "manifest.json"
+
 // AssetManifest stores the mapping between original and fingerprinted filenames
 type AssetManifest struct {
 	Assets map[string]string `json:"assets"`
+	// Integrity holds the Subresource Integrity value for each original filename
+	Integrity map[string]string `json:"integrity,omitempty"`
+	// Imports holds the original filenames of the chunks each asset imports, for manifests that record them
+	Imports map[string][]string `json:"imports,omitempty"`
+	// Incomplete is set by builds that kept going after some files failed, so
+	// the manifest lacks the assets of those files
+	Incomplete bool `json:"incomplete,omitempty"`
 }
 
 // Loader handles loading and resolving fingerprinted asset paths
 type Loader struct {
 	manifest AssetManifest
+	// fsys is the output directory the manifest was loaded from, used to serve asset content
+	fsys fs.FS
+	// baseURL is prepended to asset paths by URL, e.g. a CDN origin
+	baseURL string
+	// dev fingerprints source files on demand in place of the manifest for development loaders
+	dev *devSource
+	// minify minifies assets while runtime loaders fingerprint them
+	minify Minifier
+	// decode reads the manifest file for NewLoader; nil detects the format
+	decode ManifestDecoder
+
+	// originals and names index the manifest for reverse lookups and enumeration, built on first use
+	indexOnce sync.Once
+	originals map[string]string
+	names     []string
+
+	// integrity caches the values computed for assets the manifest has none for
+	integrityMu sync.Mutex
+	integrity   map[string]string
 }
 
-// NewLoader creates a new asset loader from a manifest file
-func NewLoader(filesys fs.FS, manifestPath string) (*Loader, error) {
-	file, err := filesys.Open(manifestPath)
+// Option configures optional Loader behaviour
+type Option func(*Loader)
+
+// WithBaseURL sets the origin used for absolute asset URLs, e.g. "https://cdn.example.com"
+func WithBaseURL(baseURL string) Option {
+	return func(l *Loader) {
+		l.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// NewLoader creates a new asset loader from a manifest file. Besides assetid's
+// own manifest it reads Vite, webpack-assets-manifest and Sprockets manifests,
+// detecting the format unless WithFormat or WithDecoder is given.
+func NewLoader(filesys fs.FS, manifestPath string, opts ...Option) (*Loader, error) {
+	loader := &Loader{}
+	for _, opt := range opts {
+		opt(loader)
+	}
+	if loader.minify != nil {
+		return nil, errMinifierUnsupported
+	}
+
+	data, err := fs.ReadFile(filesys, manifestPath)
 	if err != nil {
 		return nil, err
 	}
+
+	decode := loader.decode
+	if decode == nil {
+		format, err := DetectFormat(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", manifestPath, err)
+		}
+		decode = decoders[format]
+	}
+
+	loader.manifest, err = decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", manifestPath, err)
+	}
+
+	// fingerprinted names in the manifest are relative to the directory holding
+	// it, except for Vite 5, which writes its manifest to .vite/ in the output directory
+	dir := path.Dir(manifestPath)
+	if path.Base(dir) == ".vite" {
+		dir = path.Dir(dir)
+	}
+	loader.fsys, err = fs.Sub(filesys, dir)
+	if err != nil {
+		return nil, err
+	}
+	return loader, nil
+}
+
+// ReadManifest decodes the manifest file at manifestPath
+func ReadManifest(filesys fs.FS, manifestPath string) (AssetManifest, error) {
+	file, err := filesys.Open(manifestPath)
+	if err != nil {
+		return AssetManifest{}, err
+	}
 	defer file.Close()
 
 	var manifest AssetManifest
 	if err := json.NewDecoder(file).Decode(&manifest); err != nil {
-		return nil, err
+		return AssetManifest{}, err
 	}
+	return manifest, nil
+}
 
-	return &Loader{
-		manifest: manifest,
-	}, nil
+// NewFSLoader creates a new asset loader from an fs.FS rooted at the output
+// directory, such as an embed.FS narrowed with fs.Sub. The loader resolves
+// paths from the manifest and serves content from the same filesystem.
+func NewFSLoader(fsys fs.FS, opts ...Option) (*Loader, error) {
+	return NewLoader(fsys, ManifestFile, opts...)
 }
 
 // Path returns the fingerprinted path for a given asset
 func (l *Loader) Path(assetPath string) string {
-	if fingerprinted, ok := l.manifest.Assets[assetPath]; ok {
-		return filepath.Join("/dist", fingerprinted)
+	if fingerprinted, ok := l.lookup(assetPath); ok {
+		return filepath.Join(urlPrefix, fingerprinted)
+	}
+	return filepath.Join(urlPrefix, assetPath)
+}
+
+// URL returns the fingerprinted path for a given asset prefixed with the base URL, if one is set
+func (l *Loader) URL(assetPath string) string {
+	return l.baseURL + l.Path(assetPath)
+}
+
+// Integrity returns the Subresource Integrity value for a given asset, or an
+// empty string if it is unknown. Manifests written without integrity values
+// fall back to hashing the asset content.
+func (l *Loader) Integrity(assetPath string) string {
+	if l.dev != nil {
+		integrity, _ := l.dev.integrity(assetPath)
+		return integrity
+	}
+	if integrity, ok := l.manifest.Integrity[assetPath]; ok {
+		return integrity
+	}
+
+	l.integrityMu.Lock()
+	integrity, ok := l.integrity[assetPath]
+	l.integrityMu.Unlock()
+	if ok {
+		return integrity
+	}
+
+	content, err := l.ReadFile(assetPath)
+	if err != nil {
+		return ""
+	}
+	integrity = SRI(content)
+
+	l.integrityMu.Lock()
+	if l.integrity == nil {
+		l.integrity = make(map[string]string)
+	}
+	l.integrity[assetPath] = integrity
+	l.integrityMu.Unlock()
+	return integrity
+}
+
+// SRI returns the sha384 Subresource Integrity value for content
+func SRI(content []byte) string {
+	sum := sha512.Sum384(content)
+	return "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// Imports returns the chunks an asset imports, directly or through other
+// chunks, in the order they should be preloaded. Only manifests that record
+// imports, such as Vite's, have any.
+func (l *Loader) Imports(assetPath string) []string {
+	var imports []string
+	seen := map[string]bool{assetPath: true}
+
+	var visit func(name string)
+	visit = func(name string) {
+		for _, imported := range l.manifest.Imports[name] {
+			if seen[imported] {
+				continue
+			}
+			seen[imported] = true
+			imports = append(imports, imported)
+			visit(imported)
+		}
+	}
+	visit(assetPath)
+	return imports
+}
+
+// Original returns the original filename for a fingerprinted filename, which
+// may be given with or without the /dist/ URL prefix
+func (l *Loader) Original(fingerprinted string) (string, bool) {
+	fingerprinted = strings.TrimPrefix(fingerprinted, urlPrefix+"/")
+	if l.dev != nil {
+		return l.dev.original(fingerprinted)
+	}
+
+	l.indexOnce.Do(l.buildIndex)
+	name, ok := l.originals[fingerprinted]
+	return name, ok
+}
+
+// All returns an iterator over the original and fingerprinted filenames of
+// every asset, ordered by original filename
+func (l *Loader) All() iter.Seq2[string, string] {
+	return func(yield func(string, string) bool) {
+		for _, name := range l.assetNames() {
+			fingerprinted, ok := l.lookup(name)
+			if !ok {
+				continue
+			}
+			if !yield(name, fingerprinted) {
+				return
+			}
+		}
+	}
+}
+
+// Glob returns the sorted original filenames, and the directories containing
+// them, matching pattern, using the syntax of path.Match. Together with Open it
+// lets Loader satisfy fs.GlobFS.
+func (l *Loader) Glob(pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	var matches []string
+	seen := make(map[string]bool)
+	for _, name := range l.assetNames() {
+		// the directories of a seen name were matched with it
+		for p := name; p != "." && !seen[p]; p = path.Dir(p) {
+			seen[p] = true
+			if ok, _ := path.Match(pattern, p); ok {
+				matches = append(matches, p)
+			}
+		}
+	}
+	slices.Sort(matches)
+	return matches, nil
+}
+
+// lookup returns the fingerprinted filename for an asset's original filename
+func (l *Loader) lookup(name string) (string, bool) {
+	if l.dev != nil {
+		return l.dev.lookup(name)
+	}
+	fingerprinted, ok := l.manifest.Assets[name]
+	return fingerprinted, ok
+}
+
+// file returns the path within fsys holding the content of an asset, given its
+// original and fingerprinted filenames
+func (l *Loader) file(name, fingerprinted string) string {
+	if l.dev != nil {
+		return name
+	}
+	return fingerprinted
+}
+
+// assetNames returns the sorted original filenames of every asset
+func (l *Loader) assetNames() []string {
+	if l.dev != nil {
+		return l.dev.names()
+	}
+	l.indexOnce.Do(l.buildIndex)
+	return l.names
+}
+
+// buildIndex derives the reverse lookup and sorted names from the manifest
+func (l *Loader) buildIndex() {
+	l.originals = make(map[string]string, len(l.manifest.Assets))
+	l.names = make([]string, 0, len(l.manifest.Assets))
+	for name, fingerprinted := range l.manifest.Assets {
+		l.originals[fingerprinted] = name
+		l.names = append(l.names, name)
 	}
-	return filepath.Join("/dist", assetPath)
+	slices.Sort(l.names)
 }
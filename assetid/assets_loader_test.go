@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 	"testing/fstest"
 )
@@ -222,3 +223,131 @@ func TestWithRealFS(t *testing.T) {
 		t.Errorf("Loader.Path() = %v, want %v", got, filepath.Join("/dist", "unknown.js"))
 	}
 }
+
+func newIndexTestLoader() *Loader {
+	return &Loader{
+		manifest: AssetManifest{
+			Assets: map[string]string{
+				"app.js":             "app-12345678.js",
+				"style.css":          "style-87654321.css",
+				"img/icons/home.svg": "img/icons/home-11111111.svg",
+				"img/icons/user.svg": "img/icons/user-22222222.svg",
+				"img/logo.png":       "img/logo-33333333.png",
+			},
+		},
+	}
+}
+
+func TestLoader_Original(t *testing.T) {
+	loader := newIndexTestLoader()
+
+	tests := []struct {
+		name          string
+		fingerprinted string
+		want          string
+		wantOK        bool
+	}{
+		{name: "fingerprinted name", fingerprinted: "app-12345678.js", want: "app.js", wantOK: true},
+		{name: "nested name", fingerprinted: "img/icons/home-11111111.svg", want: "img/icons/home.svg", wantOK: true},
+		{name: "url path", fingerprinted: "/dist/style-87654321.css", want: "style.css", wantOK: true},
+		{name: "original name", fingerprinted: "app.js", wantOK: false},
+		{name: "unknown name", fingerprinted: "app-00000000.js", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := loader.Original(tt.fingerprinted)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Loader.Original(%q) = %q, %v, want %q, %v", tt.fingerprinted, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestLoader_All(t *testing.T) {
+	loader := newIndexTestLoader()
+
+	var names []string
+	for name, fingerprinted := range loader.All() {
+		if fingerprinted != loader.manifest.Assets[name] {
+			t.Errorf("All() yielded %s -> %s, want %s", name, fingerprinted, loader.manifest.Assets[name])
+		}
+		names = append(names, name)
+	}
+
+	want := []string{"app.js", "img/icons/home.svg", "img/icons/user.svg", "img/logo.png", "style.css"}
+	if !slices.Equal(names, want) {
+		t.Errorf("All() order = %v, want %v", names, want)
+	}
+
+	// Stopping early should not panic
+	for range loader.All() {
+		break
+	}
+}
+
+func TestLoader_Integrity(t *testing.T) {
+	fsys := fstest.MapFS{
+		"manifest.json":      &fstest.MapFile{Data: []byte(`{"assets":{"app.js":"app-12345678.js","style.css":"style-87654321.css"},"integrity":{"app.js":"sha384-app"}}`)},
+		"app-12345678.js":    &fstest.MapFile{Data: []byte("console.log('app');")},
+		"style-87654321.css": &fstest.MapFile{Data: []byte("body{color:red}")},
+	}
+	loader, err := NewLoader(fsys, "manifest.json")
+	if err != nil {
+		t.Fatalf("Failed to create loader: %v", err)
+	}
+
+	if got := loader.Integrity("app.js"); got != "sha384-app" {
+		t.Errorf("Loader.Integrity(app.js) = %v, want the manifest's value", got)
+	}
+	if got := loader.Integrity("missing.js"); got != "" {
+		t.Errorf("Loader.Integrity(missing.js) = %v, want empty", got)
+	}
+
+	want := SRI([]byte("body{color:red}"))
+	done := make(chan string)
+	for i := 0; i < 10; i++ {
+		go func() { done <- loader.Integrity("style.css") }()
+	}
+	for i := 0; i < 10; i++ {
+		if got := <-done; got != want {
+			t.Errorf("Loader.Integrity(style.css) = %v, want %v", got, want)
+		}
+	}
+
+	// computed values are cached, so the content is hashed once
+	fsys["style-87654321.css"] = &fstest.MapFile{Data: []byte("body{color:blue}")}
+	if got := loader.Integrity("style.css"); got != want {
+		t.Errorf("Loader.Integrity(style.css) after a change = %v, want the cached %v", got, want)
+	}
+}
+
+func TestLoader_Glob(t *testing.T) {
+	loader := newIndexTestLoader()
+
+	tests := []struct {
+		name    string
+		pattern string
+		want    []string
+		wantErr bool
+	}{
+		{name: "directory wildcard", pattern: "img/icons/*.svg", want: []string{"img/icons/home.svg", "img/icons/user.svg"}},
+		{name: "top level only", pattern: "*.js", want: []string{"app.js"}},
+		{name: "exact name", pattern: "img/logo.png", want: []string{"img/logo.png"}},
+		{name: "directories", pattern: "img/*", want: []string{"img/icons", "img/logo.png"}},
+		{name: "no matches", pattern: "*.woff2", want: nil},
+		{name: "bad pattern", pattern: "img/[", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loader.Glob(tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Loader.Glob(%q) error = %v, wantErr %v", tt.pattern, err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Loader.Glob(%q) = %v, want %v", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package assetid
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// devSource fingerprints files in a source directory on demand for development loaders
+type devSource struct {
+	fsys fs.FS
+
+	mu     sync.Mutex
+	hashes map[string]devHash
+}
+
+// devHash is a cached fingerprint, valid while the file's mtime and size are unchanged
+type devHash struct {
+	modTime   time.Time
+	size      int64
+	hash      string
+	integrity string
+}
+
+// NewDevLoader creates a loader for local development that fingerprints the
+// files in sourceDir on the fly instead of reading a manifest. Hashes use the
+// same algorithm as the build and are cached until a file's mtime or size
+// changes, so templates and handlers behave as they do with a built manifest
+// while edits show up without a rebuild.
+func NewDevLoader(sourceDir string, opts ...Option) (*Loader, error) {
+	info, err := os.Stat(sourceDir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", sourceDir)
+	}
+
+	fsys := os.DirFS(sourceDir)
+	loader := &Loader{
+		fsys: fsys,
+		dev: &devSource{
+			fsys:   fsys,
+			hashes: make(map[string]devHash),
+		},
+	}
+	for _, opt := range opts {
+		opt(loader)
+	}
+	if loader.minify != nil {
+		return nil, errMinifierUnsupported
+	}
+	return loader, nil
+}
+
+// lookup returns the current fingerprinted name of a source file
+func (d *devSource) lookup(name string) (string, bool) {
+	entry, ok := d.entry(name)
+	if !ok {
+		return "", false
+	}
+	return FingerprintedName(name, entry.hash), true
+}
+
+// integrity returns the Subresource Integrity value of a source file
+func (d *devSource) integrity(name string) (string, bool) {
+	entry, ok := d.entry(name)
+	if !ok {
+		return "", false
+	}
+	return entry.integrity, true
+}
+
+// original returns the source file a fingerprinted name refers to, as long as
+// the hash in the name matches the file's current content
+func (d *devSource) original(fingerprinted string) (string, bool) {
+	name, hash, ok := splitFingerprint(fingerprinted)
+	if !ok {
+		return "", false
+	}
+
+	entry, ok := d.entry(name)
+	if !ok || entry.hash != hash {
+		return "", false
+	}
+	return name, true
+}
+
+// names returns every file in the source directory in sorted order
+func (d *devSource) names() []string {
+	var names []string
+	err := fs.WalkDir(d.fsys, ".", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			names = append(names, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil
+	}
+
+	slices.Sort(names)
+	return names
+}
+
+// entry returns the cached fingerprint for a source file, rehashing it if it changed
+func (d *devSource) entry(name string) (devHash, bool) {
+	if !fs.ValidPath(name) {
+		return devHash{}, false
+	}
+
+	info, err := fs.Stat(d.fsys, name)
+	if err != nil || info.IsDir() {
+		return devHash{}, false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if cached, ok := d.hashes[name]; ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached, true
+	}
+
+	content, err := fs.ReadFile(d.fsys, name)
+	if err != nil {
+		return devHash{}, false
+	}
+
+	hash, err := Hash(bytes.NewReader(content))
+	if err != nil {
+		return devHash{}, false
+	}
+
+	entry := devHash{
+		modTime:   info.ModTime(),
+		size:      info.Size(),
+		hash:      hash,
+		integrity: SRI(content),
+	}
+	d.hashes[name] = entry
+	return entry, true
+}
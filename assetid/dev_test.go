@@ -0,0 +1,106 @@
+package assetid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func writeDevFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+}
+
+func TestNewDevLoader(t *testing.T) {
+	sourceDir := t.TempDir()
+	writeDevFile(t, sourceDir, "app.js", "console.log('v1');")
+	writeDevFile(t, sourceDir, "css/style.css", "body { color: red; }")
+
+	loader, err := NewDevLoader(sourceDir)
+	if err != nil {
+		t.Fatalf("NewDevLoader failed: %v", err)
+	}
+
+	hash, err := Hash(strings.NewReader("console.log('v1');"))
+	if err != nil {
+		t.Fatalf("Hash failed: %v", err)
+	}
+	wantPath := "/dist/" + FingerprintedName("app.js", hash)
+	if got := loader.Path("app.js"); got != wantPath {
+		t.Errorf("Loader.Path() = %v, want %v", got, wantPath)
+	}
+	if got := loader.Integrity("app.js"); got != SRI([]byte("console.log('v1');")) {
+		t.Errorf("Loader.Integrity() = %v, want %v", got, SRI([]byte("console.log('v1');")))
+	}
+
+	if name, ok := loader.Original(strings.TrimPrefix(wantPath, "/dist/")); !ok || name != "app.js" {
+		t.Errorf("Loader.Original() = %q, %v, want %q, true", name, ok, "app.js")
+	}
+
+	var names []string
+	for name := range loader.All() {
+		names = append(names, name)
+	}
+	if want := []string{"app.js", "css/style.css"}; !slices.Equal(names, want) {
+		t.Errorf("Loader.All() names = %v, want %v", names, want)
+	}
+
+	matches, err := loader.Glob("css/*.css")
+	if err != nil || !slices.Equal(matches, []string{"css/style.css"}) {
+		t.Errorf("Loader.Glob() = %v, %v, want [css/style.css]", matches, err)
+	}
+
+	content, err := loader.ReadFile("css/style.css")
+	if err != nil || string(content) != "body { color: red; }" {
+		t.Errorf("Loader.ReadFile() = %q, %v", content, err)
+	}
+
+	rec := httptest.NewRecorder()
+	loader.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, wantPath, nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "console.log('v1');" {
+		t.Errorf("Handler() = %d %q, want 200 with source content", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Cache-Control"); got != "no-cache" {
+		t.Errorf("Cache-Control = %q, want no-cache", got)
+	}
+
+	// Editing the file changes its fingerprint and retires the old one
+	writeDevFile(t, sourceDir, "app.js", "console.log('version 2');")
+
+	if got := loader.Path("app.js"); got == wantPath {
+		t.Errorf("Loader.Path() did not change after editing the file")
+	}
+	if _, ok := loader.Original(strings.TrimPrefix(wantPath, "/dist/")); ok {
+		t.Errorf("Loader.Original() still resolves the stale fingerprint")
+	}
+
+	// Unknown files fall back to the unhashed path
+	if got := loader.Path("missing.js"); got != "/dist/missing.js" {
+		t.Errorf("Loader.Path() = %v, want /dist/missing.js", got)
+	}
+}
+
+func TestNewDevLoaderErrors(t *testing.T) {
+	if _, err := NewDevLoader(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected error for missing directory, got nil")
+	}
+
+	file := filepath.Join(t.TempDir(), "file.js")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := NewDevLoader(file); err == nil {
+		t.Error("Expected error for file source, got nil")
+	}
+}
@@ -0,0 +1,123 @@
+package assetid
+
+import (
+	"slices"
+)
+
+// ManifestDiff describes how assets changed between two manifests
+type ManifestDiff struct {
+	// Added lists assets only in the new manifest
+	Added []DiffEntry `json:"added"`
+	// Removed lists assets only in the old manifest
+	Removed []DiffEntry `json:"removed"`
+	// Changed lists assets whose original name is unchanged but whose fingerprint differs
+	Changed []DiffEntry `json:"changed"`
+	// Renamed lists assets whose content is unchanged but whose original name differs
+	Renamed []DiffEntry `json:"renamed"`
+	// Unchanged is the number of assets with the same name and fingerprint in both manifests
+	Unchanged int `json:"unchanged"`
+}
+
+// DiffEntry describes one asset in a ManifestDiff. Old and New are the
+// fingerprinted filenames on each side; OldAsset is set for renamed assets.
+type DiffEntry struct {
+	Asset    string `json:"asset"`
+	OldAsset string `json:"oldAsset,omitempty"`
+	Old      string `json:"old,omitempty"`
+	New      string `json:"new,omitempty"`
+}
+
+// Empty reports whether the manifests describe the same assets
+func (d *ManifestDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0 && len(d.Renamed) == 0
+}
+
+// StaleFiles returns the sorted fingerprinted filenames from the old manifest
+// that no longer serve current content: removed, changed and renamed assets
+func (d *ManifestDiff) StaleFiles() []string {
+	var files []string
+	for _, entries := range [][]DiffEntry{d.Removed, d.Changed, d.Renamed} {
+		for _, entry := range entries {
+			files = append(files, entry.Old)
+		}
+	}
+	slices.Sort(files)
+	return slices.Compact(files)
+}
+
+// DiffManifests compares two manifests. An asset that disappears under one
+// name and appears under another with identical content is reported as
+// renamed rather than as a removal and an addition.
+func DiffManifests(oldManifest, newManifest AssetManifest) *ManifestDiff {
+	diff := &ManifestDiff{
+		Added:   []DiffEntry{},
+		Removed: []DiffEntry{},
+		Changed: []DiffEntry{},
+		Renamed: []DiffEntry{},
+	}
+
+	for _, name := range sortedNames(oldManifest) {
+		oldFile := oldManifest.Assets[name]
+		newFile, ok := newManifest.Assets[name]
+		switch {
+		case !ok:
+			diff.Removed = append(diff.Removed, DiffEntry{Asset: name, Old: oldFile})
+		case oldFile != newFile || !sameIntegrity(oldManifest, newManifest, name, name):
+			diff.Changed = append(diff.Changed, DiffEntry{Asset: name, Old: oldFile, New: newFile})
+		default:
+			diff.Unchanged++
+		}
+	}
+
+	for _, name := range sortedNames(newManifest) {
+		if _, ok := oldManifest.Assets[name]; ok {
+			continue
+		}
+		newFile := newManifest.Assets[name]
+
+		i := slices.IndexFunc(diff.Removed, func(removed DiffEntry) bool {
+			return sameContent(oldManifest, newManifest, removed.Asset, name)
+		})
+		if i < 0 {
+			diff.Added = append(diff.Added, DiffEntry{Asset: name, New: newFile})
+			continue
+		}
+
+		removed := diff.Removed[i]
+		diff.Removed = slices.Delete(diff.Removed, i, i+1)
+		diff.Renamed = append(diff.Renamed, DiffEntry{Asset: name, OldAsset: removed.Asset, Old: removed.Old, New: newFile})
+	}
+
+	return diff
+}
+
+// sortedNames returns the original filenames of a manifest in sorted order
+func sortedNames(manifest AssetManifest) []string {
+	names := make([]string, 0, len(manifest.Assets))
+	for name := range manifest.Assets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// sameIntegrity reports whether two assets' integrity values match, or are unknown on either side
+func sameIntegrity(oldManifest, newManifest AssetManifest, oldName, newName string) bool {
+	oldIntegrity, oldOK := oldManifest.Integrity[oldName]
+	newIntegrity, newOK := newManifest.Integrity[newName]
+	return !oldOK || !newOK || oldIntegrity == newIntegrity
+}
+
+// sameContent reports whether two assets have identical content, judged by
+// integrity values when both manifests have them and by fingerprint otherwise
+func sameContent(oldManifest, newManifest AssetManifest, oldName, newName string) bool {
+	oldIntegrity, oldOK := oldManifest.Integrity[oldName]
+	newIntegrity, newOK := newManifest.Integrity[newName]
+	if oldOK && newOK {
+		return oldIntegrity == newIntegrity
+	}
+
+	_, oldHash, oldOK := splitFingerprint(oldManifest.Assets[oldName])
+	_, newHash, newOK := splitFingerprint(newManifest.Assets[newName])
+	return oldOK && newOK && oldHash == newHash
+}
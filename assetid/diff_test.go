@@ -0,0 +1,80 @@
+package assetid
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDiffManifests(t *testing.T) {
+	oldManifest := AssetManifest{
+		Assets: map[string]string{
+			"app.js":       "app-1111111111111111.js",
+			"style.css":    "style-2222222222222222.css",
+			"legacy.js":    "legacy-3333333333333333.js",
+			"img/logo.png": "img/logo-4444444444444444.png",
+			"index.html":   "index.html",
+		},
+		Integrity: map[string]string{
+			"index.html": "sha384-old",
+		},
+	}
+	newManifest := AssetManifest{
+		Assets: map[string]string{
+			"app.js":             "app-aaaaaaaaaaaaaaaa.js",
+			"style.css":          "style-2222222222222222.css",
+			"images/logo.png":    "images/logo-4444444444444444.png",
+			"vendor/chart.js":    "vendor/chart-5555555555555555.js",
+			"index.html":         "index.html",
+			"img/icons/home.svg": "img/icons/home-6666666666666666.svg",
+		},
+		Integrity: map[string]string{
+			"index.html": "sha384-new",
+		},
+	}
+
+	diff := DiffManifests(oldManifest, newManifest)
+
+	wantAdded := []DiffEntry{
+		{Asset: "img/icons/home.svg", New: "img/icons/home-6666666666666666.svg"},
+		{Asset: "vendor/chart.js", New: "vendor/chart-5555555555555555.js"},
+	}
+	if !slices.Equal(diff.Added, wantAdded) {
+		t.Errorf("Added = %+v, want %+v", diff.Added, wantAdded)
+	}
+
+	wantRemoved := []DiffEntry{{Asset: "legacy.js", Old: "legacy-3333333333333333.js"}}
+	if !slices.Equal(diff.Removed, wantRemoved) {
+		t.Errorf("Removed = %+v, want %+v", diff.Removed, wantRemoved)
+	}
+
+	wantChanged := []DiffEntry{
+		{Asset: "app.js", Old: "app-1111111111111111.js", New: "app-aaaaaaaaaaaaaaaa.js"},
+		{Asset: "index.html", Old: "index.html", New: "index.html"},
+	}
+	if !slices.Equal(diff.Changed, wantChanged) {
+		t.Errorf("Changed = %+v, want %+v", diff.Changed, wantChanged)
+	}
+
+	wantRenamed := []DiffEntry{
+		{Asset: "images/logo.png", OldAsset: "img/logo.png", Old: "img/logo-4444444444444444.png", New: "images/logo-4444444444444444.png"},
+	}
+	if !slices.Equal(diff.Renamed, wantRenamed) {
+		t.Errorf("Renamed = %+v, want %+v", diff.Renamed, wantRenamed)
+	}
+
+	if diff.Unchanged != 1 {
+		t.Errorf("Unchanged = %d, want 1", diff.Unchanged)
+	}
+	if diff.Empty() {
+		t.Error("Empty() = true, want false")
+	}
+
+	wantStale := []string{"app-1111111111111111.js", "img/logo-4444444444444444.png", "index.html", "legacy-3333333333333333.js"}
+	if got := diff.StaleFiles(); !slices.Equal(got, wantStale) {
+		t.Errorf("StaleFiles() = %v, want %v", got, wantStale)
+	}
+
+	if same := DiffManifests(oldManifest, oldManifest); !same.Empty() || same.Unchanged != len(oldManifest.Assets) {
+		t.Errorf("Diff of identical manifests = %+v, want empty", same)
+	}
+}
@@ -0,0 +1,132 @@
+package assetid
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"time"
+)
+
+// ManifestFormat is a manifest format of another ecosystem
+type ManifestFormat string
+
+const (
+	// FormatAssetid is the manifest.json written by assetid builds
+	FormatAssetid ManifestFormat = "assetid"
+	// FormatVite is Vite's build manifest, read by backend integrations such as vite_ruby and laravel-vite
+	FormatVite ManifestFormat = "vite"
+	// FormatWebpack is the flat manifest written by webpack-assets-manifest
+	FormatWebpack ManifestFormat = "webpack"
+	// FormatMix is Laravel Mix's mix-manifest.json, read by the mix() helper
+	FormatMix ManifestFormat = "mix"
+	// FormatSprockets is the Rails asset pipeline's .sprockets-manifest-*.json
+	FormatSprockets ManifestFormat = "sprockets"
+)
+
+// sprocketsManifestFile is found by Sprockets through the .sprockets-manifest-*.json
+// pattern; the suffix is fixed so every build overwrites the same file
+const sprocketsManifestFile = ".sprockets-manifest-00000000000000000000000000a55e71.json"
+
+// ExportFormats lists every format Export can write
+func ExportFormats() []ManifestFormat {
+	return []ManifestFormat{FormatVite, FormatWebpack, FormatMix, FormatSprockets}
+}
+
+// File returns the path, relative to the output directory, that the format is written to
+func (f ManifestFormat) File() string {
+	switch f {
+	case FormatAssetid:
+		return ManifestFile
+	case FormatVite:
+		return ".vite/manifest.json"
+	case FormatWebpack:
+		return "assets-manifest.json"
+	case FormatMix:
+		return "mix-manifest.json"
+	case FormatSprockets:
+		return sprocketsManifestFile
+	}
+	return ""
+}
+
+// viteChunk is an entry of a Vite manifest
+type viteChunk struct {
+	File      string `json:"file"`
+	Src       string `json:"src"`
+	IsEntry   bool   `json:"isEntry,omitempty"`
+	Integrity string `json:"integrity,omitempty"`
+}
+
+// sprocketsManifest is the layout of a Sprockets manifest
+type sprocketsManifest struct {
+	Files  map[string]sprocketsFile `json:"files"`
+	Assets map[string]string        `json:"assets"`
+}
+
+// sprocketsFile describes one compiled file of a Sprockets manifest
+type sprocketsFile struct {
+	LogicalPath string    `json:"logical_path"`
+	MTime       time.Time `json:"mtime"`
+	Size        int64     `json:"size"`
+	Digest      string    `json:"digest"`
+	Integrity   string    `json:"integrity,omitempty"`
+}
+
+// Export encodes manifest in the given format. fsys is the output directory
+// the manifest describes, used for the file sizes and times some formats record.
+func Export(fsys fs.FS, manifest AssetManifest, format ManifestFormat) ([]byte, error) {
+	var out any
+
+	switch format {
+	case FormatVite:
+		chunks := make(map[string]viteChunk, len(manifest.Assets))
+		for name, fingerprinted := range manifest.Assets {
+			ext := path.Ext(name)
+			chunks[name] = viteChunk{
+				File:      fingerprinted,
+				Src:       name,
+				IsEntry:   ext == ".js" || ext == ".mjs" || ext == ".css",
+				Integrity: manifest.Integrity[name],
+			}
+		}
+		out = chunks
+
+	case FormatWebpack:
+		out = manifest.Assets
+
+	case FormatMix:
+		paths := make(map[string]string, len(manifest.Assets))
+		for name, fingerprinted := range manifest.Assets {
+			paths["/"+name] = "/" + fingerprinted
+		}
+		out = paths
+
+	case FormatSprockets:
+		files := make(map[string]sprocketsFile, len(manifest.Assets))
+		for name, fingerprinted := range manifest.Assets {
+			info, err := fs.Stat(fsys, fingerprinted)
+			if err != nil {
+				return nil, fmt.Errorf("failed to stat %s: %w", fingerprinted, err)
+			}
+			_, hash, _ := splitFingerprint(fingerprinted)
+			files[fingerprinted] = sprocketsFile{
+				LogicalPath: name,
+				MTime:       info.ModTime().UTC().Truncate(time.Second),
+				Size:        info.Size(),
+				Digest:      hash,
+				Integrity:   manifest.Integrity[name],
+			}
+		}
+		out = sprocketsManifest{Files: files, Assets: manifest.Assets}
+
+	default:
+		return nil, fmt.Errorf("unknown manifest format %q", format)
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s manifest: %w", format, err)
+	}
+	return append(data, '\n'), nil
+}
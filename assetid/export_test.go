@@ -0,0 +1,111 @@
+package assetid
+
+import (
+	"encoding/json"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestExport(t *testing.T) {
+	modTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"app-1234567890abcdef.js":       &fstest.MapFile{Data: []byte("console.log(1)"), ModTime: modTime},
+		"img/logo-1234567890abcdef.png": &fstest.MapFile{Data: []byte("png"), ModTime: modTime},
+	}
+	manifest := AssetManifest{
+		Assets: map[string]string{
+			"app.js":       "app-1234567890abcdef.js",
+			"img/logo.png": "img/logo-1234567890abcdef.png",
+		},
+		Integrity: map[string]string{"app.js": "sha384-app"},
+	}
+
+	tests := []struct {
+		format ManifestFormat
+		file   string
+		want   string
+	}{
+		{
+			format: FormatVite,
+			file:   ".vite/manifest.json",
+			want: `{
+				"app.js": {"file": "app-1234567890abcdef.js", "src": "app.js", "isEntry": true, "integrity": "sha384-app"},
+				"img/logo.png": {"file": "img/logo-1234567890abcdef.png", "src": "img/logo.png"}
+			}`,
+		},
+		{
+			format: FormatWebpack,
+			file:   "assets-manifest.json",
+			want: `{
+				"app.js": "app-1234567890abcdef.js",
+				"img/logo.png": "img/logo-1234567890abcdef.png"
+			}`,
+		},
+		{
+			format: FormatMix,
+			file:   "mix-manifest.json",
+			want: `{
+				"/app.js": "/app-1234567890abcdef.js",
+				"/img/logo.png": "/img/logo-1234567890abcdef.png"
+			}`,
+		},
+		{
+			format: FormatSprockets,
+			file:   sprocketsManifestFile,
+			want: `{
+				"files": {
+					"app-1234567890abcdef.js": {
+						"logical_path": "app.js", "mtime": "2024-05-01T12:00:00Z", "size": 14,
+						"digest": "1234567890abcdef", "integrity": "sha384-app"
+					},
+					"img/logo-1234567890abcdef.png": {
+						"logical_path": "img/logo.png", "mtime": "2024-05-01T12:00:00Z", "size": 3,
+						"digest": "1234567890abcdef"
+					}
+				},
+				"assets": {
+					"app.js": "app-1234567890abcdef.js",
+					"img/logo.png": "img/logo-1234567890abcdef.png"
+				}
+			}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			if got := tt.format.File(); got != tt.file {
+				t.Errorf("File() = %s, want %s", got, tt.file)
+			}
+
+			data, err := Export(fsys, manifest, tt.format)
+			if err != nil {
+				t.Fatalf("Export failed: %v", err)
+			}
+
+			var got, want any
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("Export wrote invalid JSON: %v\n%s", err, data)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatalf("Invalid expectation: %v", err)
+			}
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(want)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("Export() = %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+func TestExportErrors(t *testing.T) {
+	manifest := AssetManifest{Assets: map[string]string{"app.js": "app-1234567890abcdef.js"}}
+
+	if _, err := Export(fstest.MapFS{}, manifest, "parcel"); err == nil {
+		t.Error("Expected error for unknown format, got nil")
+	}
+	if _, err := Export(fstest.MapFS{}, manifest, FormatSprockets); err == nil {
+		t.Error("Expected error for missing file, got nil")
+	}
+}
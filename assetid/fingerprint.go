@@ -0,0 +1,47 @@
+package assetid
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"path"
+	"strings"
+)
+
+// hashLength is the number of hex characters in a content hash
+const hashLength = 16
+
+// Hash returns the content hash used in fingerprinted filenames: the FNV-64a
+// sum of everything read from r as 16 hex characters
+func Hash(r io.Reader) (string, error) {
+	hash := fnv.New64a()
+	if _, err := io.Copy(hash, r); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%016x", hash.Sum64()), nil
+}
+
+// FingerprintedName inserts hash before the extension of name, e.g. app.js -> app-<hash>.js
+func FingerprintedName(name, hash string) string {
+	ext := path.Ext(name)
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(name, ext), hash, ext)
+}
+
+// splitFingerprint reverses FingerprintedName, returning the original name and hash
+func splitFingerprint(fingerprinted string) (string, string, bool) {
+	ext := path.Ext(fingerprinted)
+	base := strings.TrimSuffix(fingerprinted, ext)
+
+	i := strings.LastIndexByte(base, '-')
+	if i < 0 || len(base)-i-1 != hashLength {
+		return "", "", false
+	}
+
+	hash := base[i+1:]
+	for _, c := range hash {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return "", "", false
+		}
+	}
+	return base[:i] + ext, hash, true
+}
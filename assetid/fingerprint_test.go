@@ -0,0 +1,65 @@
+package assetid
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	hash, err := Hash(strings.NewReader("test content for hashing"))
+	if err != nil {
+		t.Fatalf("Hash failed: %v", err)
+	}
+	if len(hash) != hashLength {
+		t.Errorf("Expected hash length %d, got %d", hashLength, len(hash))
+	}
+
+	hash2, err := Hash(strings.NewReader("test content for hashing"))
+	if err != nil {
+		t.Fatalf("Second Hash failed: %v", err)
+	}
+	if hash != hash2 {
+		t.Errorf("Hashes do not match for same content: %s vs %s", hash, hash2)
+	}
+
+	other, err := Hash(strings.NewReader("other content"))
+	if err != nil {
+		t.Fatalf("Third Hash failed: %v", err)
+	}
+	if hash == other {
+		t.Errorf("Hashes match for different content: %s", hash)
+	}
+}
+
+func TestFingerprintedName(t *testing.T) {
+	tests := []struct {
+		name string
+		hash string
+		want string
+	}{
+		{name: "app.js", hash: "a1b2c3d4e5f67890", want: "app-a1b2c3d4e5f67890.js"},
+		{name: "img/logo.png", hash: "a1b2c3d4e5f67890", want: "img/logo-a1b2c3d4e5f67890.png"},
+		{name: "vendor/lib.min.js", hash: "a1b2c3d4e5f67890", want: "vendor/lib.min-a1b2c3d4e5f67890.js"},
+		{name: "LICENSE", hash: "a1b2c3d4e5f67890", want: "LICENSE-a1b2c3d4e5f67890"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FingerprintedName(tt.name, tt.hash)
+			if got != tt.want {
+				t.Errorf("FingerprintedName(%q, %q) = %q, want %q", tt.name, tt.hash, got, tt.want)
+			}
+
+			name, hash, ok := splitFingerprint(got)
+			if !ok || name != tt.name || hash != tt.hash {
+				t.Errorf("splitFingerprint(%q) = %q, %q, %v, want %q, %q, true", got, name, hash, ok, tt.name, tt.hash)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"app.js", "app-1234.js", "my-app.js", "app-A1B2C3D4E5F67890.js"} {
+		if _, _, ok := splitFingerprint(invalid); ok {
+			t.Errorf("splitFingerprint(%q) succeeded, want failure", invalid)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package assetid
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Loader serves asset content by original filename through the standard filesystem interfaces
+var (
+	_ fs.FS          = (*Loader)(nil)
+	_ fs.ReadFileFS  = (*Loader)(nil)
+	_ fs.StatFS      = (*Loader)(nil)
+	_ fs.GlobFS      = (*Loader)(nil)
+	_ fs.ReadDirFile = (*assetDir)(nil)
+)
+
+// Open opens the fingerprinted file for an asset, named by its original
+// filename. The returned file reports the original filename from Stat.
+// Directories are derived from the original filenames, so "." lists every
+// top-level asset and directory.
+func (l *Loader) Open(name string) (fs.File, error) {
+	file, err := l.resolve("open", name)
+	if errors.Is(err, fs.ErrNotExist) {
+		if entries, ok := l.dirEntries(name); ok {
+			return &assetDir{name: name, entries: entries}, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := l.fsys.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	return &assetFile{File: f, name: name}, nil
+}
+
+// ReadFile returns the content of the fingerprinted file for an asset, named by its original filename
+func (l *Loader) ReadFile(name string) ([]byte, error) {
+	file, err := l.resolve("read", name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadFile(l.fsys, file)
+}
+
+// Stat returns file info for the fingerprinted file of an asset, named by its
+// original filename, or for a directory of assets
+func (l *Loader) Stat(name string) (fs.FileInfo, error) {
+	file, err := l.resolve("stat", name)
+	if errors.Is(err, fs.ErrNotExist) {
+		if _, ok := l.dirEntries(name); ok {
+			return dirInfo{name: path.Base(name)}, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := fs.Stat(l.fsys, file)
+	if err != nil {
+		return nil, err
+	}
+	return assetInfo{FileInfo: info, name: path.Base(name)}, nil
+}
+
+// resolve returns the path within fsys holding the content of an asset, named by its original filename
+func (l *Loader) resolve(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	fingerprinted, ok := l.lookup(name)
+	if !ok || l.fsys == nil {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return l.file(name, fingerprinted), nil
+}
+
+// dirEntries returns the sorted entries of a directory of assets, and whether
+// dir is one: "." or the directory of an original filename
+func (l *Loader) dirEntries(dir string) ([]fs.DirEntry, bool) {
+	if !fs.ValidPath(dir) {
+		return nil, false
+	}
+
+	prefix := dir + "/"
+	if dir == "." {
+		prefix = ""
+	}
+	isDir := make(map[string]bool)
+	for _, name := range l.assetNames() {
+		rel, ok := strings.CutPrefix(name, prefix)
+		if !ok {
+			continue
+		}
+		child, _, nested := strings.Cut(rel, "/")
+		isDir[child] = isDir[child] || nested
+	}
+	if len(isDir) == 0 && dir != "." {
+		return nil, false
+	}
+
+	entries := make([]fs.DirEntry, 0, len(isDir))
+	for child, childIsDir := range isDir {
+		entries = append(entries, assetDirEntry{loader: l, path: path.Join(dir, child), isDir: childIsDir})
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return entries, true
+}
+
+// assetDir is an open directory of assets
+type assetDir struct {
+	name    string
+	entries []fs.DirEntry
+}
+
+func (d *assetDir) Stat() (fs.FileInfo, error) { return dirInfo{name: path.Base(d.name)}, nil }
+func (d *assetDir) Close() error               { return nil }
+
+func (d *assetDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errors.New("is a directory")}
+}
+
+// ReadDir returns the next n entries of the directory, or all remaining ones if n <= 0
+func (d *assetDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(d.entries))
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
+
+// assetDirEntry is an asset or directory of assets listed by assetDir
+type assetDirEntry struct {
+	loader *Loader
+	path   string
+	isDir  bool
+}
+
+func (e assetDirEntry) Name() string { return path.Base(e.path) }
+func (e assetDirEntry) IsDir() bool  { return e.isDir }
+func (e assetDirEntry) Type() fs.FileMode {
+	if e.isDir {
+		return fs.ModeDir
+	}
+	return 0
+}
+func (e assetDirEntry) Info() (fs.FileInfo, error) { return e.loader.Stat(e.path) }
+
+// dirInfo describes a directory of assets
+type dirInfo struct {
+	name string
+}
+
+func (i dirInfo) Name() string       { return i.name }
+func (i dirInfo) Size() int64        { return 0 }
+func (i dirInfo) Mode() fs.FileMode  { return fs.ModeDir | 0555 }
+func (i dirInfo) ModTime() time.Time { return time.Time{} }
+func (i dirInfo) IsDir() bool        { return true }
+func (i dirInfo) Sys() any           { return nil }
+
+// assetFile is a fingerprinted file presented under its original filename
+type assetFile struct {
+	fs.File
+	name string
+}
+
+func (f *assetFile) Stat() (fs.FileInfo, error) {
+	info, err := f.File.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return assetInfo{FileInfo: info, name: path.Base(f.name)}, nil
+}
+
+// Seek forwards to the underlying file so assets can be served through http.FS
+func (f *assetFile) Seek(offset int64, whence int) (int64, error) {
+	seeker, ok := f.File.(io.Seeker)
+	if !ok {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: errors.ErrUnsupported}
+	}
+	return seeker.Seek(offset, whence)
+}
+
+// ReadAt forwards to the underlying file when it supports random access
+func (f *assetFile) ReadAt(p []byte, off int64) (int, error) {
+	readerAt, ok := f.File.(io.ReaderAt)
+	if !ok {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: errors.ErrUnsupported}
+	}
+	return readerAt.ReadAt(p, off)
+}
+
+// assetInfo reports the original filename in place of the fingerprinted one
+type assetInfo struct {
+	fs.FileInfo
+	name string
+}
+
+func (i assetInfo) Name() string {
+	return i.name
+}
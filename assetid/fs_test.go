@@ -0,0 +1,177 @@
+package assetid
+
+import (
+	"errors"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func newTestFSLoader(t *testing.T) *Loader {
+	t.Helper()
+
+	loader, err := NewFSLoader(fstest.MapFS{
+		"manifest.json": &fstest.MapFile{
+			Data: []byte(`{"assets":{"app.js":"app-12345678.js","email/welcome.html":"email/welcome-87654321.html","gone.js":"gone-12345678.js"}}`),
+		},
+		"app-12345678.js":             &fstest.MapFile{Data: []byte("console.log('app');")},
+		"email/welcome-87654321.html": &fstest.MapFile{Data: []byte(`<p>{{.}}</p>`)},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create loader: %v", err)
+	}
+	return loader
+}
+
+func TestLoader_Open(t *testing.T) {
+	loader := newTestFSLoader(t)
+
+	file, err := loader.Open("app.js")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if string(content) != "console.log('app');" {
+		t.Errorf("content = %q, want %q", content, "console.log('app');")
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Name() != "app.js" {
+		t.Errorf("Stat().Name() = %q, want %q", info.Name(), "app.js")
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr error
+	}{
+		{name: "unknown asset", path: "unknown.js", wantErr: fs.ErrNotExist},
+		{name: "fingerprinted name", path: "app-12345678.js", wantErr: fs.ErrNotExist},
+		{name: "missing file", path: "gone.js", wantErr: fs.ErrNotExist},
+		{name: "invalid path", path: "../app.js", wantErr: fs.ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loader.Open(tt.path); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Open(%q) error = %v, want %v", tt.path, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoader_ReadFileAndStat(t *testing.T) {
+	loader := newTestFSLoader(t)
+
+	content, err := fs.ReadFile(loader, "email/welcome.html")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(content) != `<p>{{.}}</p>` {
+		t.Errorf("content = %q, want %q", content, `<p>{{.}}</p>`)
+	}
+
+	info, err := fs.Stat(loader, "email/welcome.html")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Name() != "welcome.html" {
+		t.Errorf("Name() = %q, want %q", info.Name(), "welcome.html")
+	}
+	if info.Size() != int64(len(content)) {
+		t.Errorf("Size() = %d, want %d", info.Size(), len(content))
+	}
+
+	if _, err := loader.ReadFile("unknown.js"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ReadFile(unknown.js) error = %v, want %v", err, fs.ErrNotExist)
+	}
+	if _, err := loader.Stat("unknown.js"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Stat(unknown.js) error = %v, want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestLoader_TestFS(t *testing.T) {
+	source := fstest.MapFS{
+		"app.js":             &fstest.MapFile{Data: []byte("console.log('app');")},
+		"css/site.css":       &fstest.MapFile{Data: []byte("body{}")},
+		"email/welcome.html": &fstest.MapFile{Data: []byte(`<p>{{.}}</p>`)},
+		"email/img/logo.png": &fstest.MapFile{Data: []byte("png")},
+	}
+	built, err := NewFSLoader(fstest.MapFS{
+		"manifest.json":               &fstest.MapFile{Data: []byte(`{"assets":{"app.js":"app-12345678.js","css/site.css":"css/site-12345678.css","email/welcome.html":"email/welcome-87654321.html"}}`)},
+		"app-12345678.js":             source["app.js"],
+		"css/site-12345678.css":       source["css/site.css"],
+		"email/welcome-87654321.html": source["email/welcome.html"],
+	})
+	if err != nil {
+		t.Fatalf("Failed to create loader: %v", err)
+	}
+	runtime, err := NewRuntimeLoader(source)
+	if err != nil {
+		t.Fatalf("Failed to create runtime loader: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		loader *Loader
+		files  []string
+	}{
+		{name: "manifest", loader: built, files: []string{"app.js", "css/site.css", "email/welcome.html"}},
+		{name: "runtime", loader: runtime, files: []string{"app.js", "css/site.css", "email/welcome.html", "email/img/logo.png"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := fstest.TestFS(tt.loader, tt.files...); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestLoader_FSIntegration(t *testing.T) {
+	loader := newTestFSLoader(t)
+
+	t.Run("http.FS", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		http.FileServer(http.FS(loader)).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/app.js", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+		}
+		if rec.Body.String() != "console.log('app');" {
+			t.Errorf("body = %q, want %q", rec.Body.String(), "console.log('app');")
+		}
+		if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/javascript") {
+			t.Errorf("Content-Type = %q, want text/javascript", rec.Header().Get("Content-Type"))
+		}
+	})
+
+	t.Run("template.ParseFS", func(t *testing.T) {
+		tmpl, err := template.ParseFS(loader, "email/welcome.html")
+		if err != nil {
+			t.Fatalf("ParseFS failed: %v", err)
+		}
+
+		var b strings.Builder
+		if err := tmpl.Execute(&b, "Ada"); err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if b.String() != "<p>Ada</p>" {
+			t.Errorf("output = %q, want %q", b.String(), "<p>Ada</p>")
+		}
+	})
+}
@@ -0,0 +1,150 @@
+package assetid
+
+import (
+	"fmt"
+	"html/template"
+	"path"
+	"strings"
+)
+
+// FuncMap returns template functions for referencing assets from html/template:
+//
+//	asset          fingerprinted path, e.g. /dist/app-a1b2c3d4e5f67890.js
+//	asset_url      fingerprinted path prefixed with the base URL
+//	integrity      Subresource Integrity value
+//	script_tag     <script> element; accepts "module", "defer" and "async" flags
+//	stylesheet_tag <link rel="stylesheet"> element
+//	preload_tag    <link rel="preload"> (or "modulepreload") element
+//	inline_css     asset content for use inside <style>
+//	inline_js      asset content for use inside <script>
+//
+// Tags carry integrity and crossorigin attributes when an integrity value is
+// known, and scripts with a .mjs extension are emitted as ES modules.
+func (l *Loader) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"asset":          l.Path,
+		"asset_url":      l.URL,
+		"integrity":      l.Integrity,
+		"script_tag":     l.scriptTag,
+		"stylesheet_tag": l.stylesheetTag,
+		"preload_tag":    l.preloadTag,
+		"inline_css":     l.inlineCSS,
+		"inline_js":      l.inlineJS,
+	}
+}
+
+// scriptTag renders a <script> element for an asset
+func (l *Loader) scriptTag(assetPath string, flags ...string) (template.HTML, error) {
+	var b strings.Builder
+	b.WriteString("<script")
+	if isModule(assetPath) {
+		writeAttr(&b, "type", "module")
+	}
+	for _, flag := range flags {
+		switch flag {
+		case "module":
+			if !isModule(assetPath) {
+				writeAttr(&b, "type", "module")
+			}
+		case "defer", "async":
+			b.WriteString(" " + flag)
+		default:
+			return "", fmt.Errorf("script_tag: unknown flag %q", flag)
+		}
+	}
+	writeAttr(&b, "src", l.URL(assetPath))
+	l.writeIntegrity(&b, assetPath)
+	b.WriteString("></script>")
+	return template.HTML(b.String()), nil
+}
+
+// stylesheetTag renders a <link rel="stylesheet"> element for an asset
+func (l *Loader) stylesheetTag(assetPath string) template.HTML {
+	var b strings.Builder
+	b.WriteString("<link")
+	writeAttr(&b, "rel", "stylesheet")
+	writeAttr(&b, "href", l.URL(assetPath))
+	l.writeIntegrity(&b, assetPath)
+	b.WriteString(">")
+	return template.HTML(b.String())
+}
+
+// preloadTag renders a <link rel="preload"> element for an asset, using the
+// asset's extension to pick the "as" destination
+func (l *Loader) preloadTag(assetPath string) template.HTML {
+	var b strings.Builder
+	b.WriteString("<link")
+	if isModule(assetPath) {
+		writeAttr(&b, "rel", "modulepreload")
+	} else {
+		writeAttr(&b, "rel", "preload")
+	}
+	writeAttr(&b, "href", l.URL(assetPath))
+
+	as := preloadDestination(assetPath)
+	if !isModule(assetPath) {
+		writeAttr(&b, "as", as)
+	}
+	if !l.writeIntegrity(&b, assetPath) && (as == "font" || as == "fetch") {
+		// fonts and fetches are always made in CORS mode, so the preload must match
+		writeAttr(&b, "crossorigin", "anonymous")
+	}
+	b.WriteString(">")
+	return template.HTML(b.String())
+}
+
+// inlineCSS returns the content of an asset for embedding in a <style> element
+func (l *Loader) inlineCSS(assetPath string) (template.CSS, error) {
+	content, err := l.ReadFile(assetPath)
+	if err != nil {
+		return "", fmt.Errorf("inline_css: %w", err)
+	}
+	return template.CSS(content), nil
+}
+
+// inlineJS returns the content of an asset for embedding in a <script> element
+func (l *Loader) inlineJS(assetPath string) (template.JS, error) {
+	content, err := l.ReadFile(assetPath)
+	if err != nil {
+		return "", fmt.Errorf("inline_js: %w", err)
+	}
+	return template.JS(content), nil
+}
+
+// writeIntegrity adds integrity and crossorigin attributes when the asset's
+// integrity is known, reporting whether it did
+func (l *Loader) writeIntegrity(b *strings.Builder, assetPath string) bool {
+	integrity := l.Integrity(assetPath)
+	if integrity == "" {
+		return false
+	}
+	writeAttr(b, "integrity", integrity)
+	writeAttr(b, "crossorigin", "anonymous")
+	return true
+}
+
+// writeAttr writes an escaped attribute with a leading space
+func writeAttr(b *strings.Builder, name, value string) {
+	fmt.Fprintf(b, ` %s="%s"`, name, template.HTMLEscapeString(value))
+}
+
+// isModule reports whether an asset is an ES module
+func isModule(assetPath string) bool {
+	return path.Ext(assetPath) == ".mjs"
+}
+
+// preloadDestination returns the preload "as" value for an asset
+func preloadDestination(assetPath string) string {
+	switch strings.ToLower(path.Ext(assetPath)) {
+	case ".js", ".mjs":
+		return "script"
+	case ".css":
+		return "style"
+	case ".woff", ".woff2", ".ttf", ".otf", ".eot":
+		return "font"
+	case ".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp", ".avif", ".ico":
+		return "image"
+	default:
+		return "fetch"
+	}
+}
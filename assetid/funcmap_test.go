@@ -0,0 +1,147 @@
+package assetid
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoader_FuncMap(t *testing.T) {
+	fs := fstest.MapFS{
+		"manifest.json": &fstest.MapFile{
+			Data: []byte(`{
+				"assets": {
+					"app.js": "app-12345678.js",
+					"main.mjs": "main-12345678.mjs",
+					"style.css": "style-87654321.css",
+					"font.woff2": "font-11111111.woff2"
+				},
+				"integrity": {
+					"app.js": "sha384-app",
+					"main.mjs": "sha384-main"
+				}
+			}`),
+		},
+		"style-87654321.css": &fstest.MapFile{Data: []byte("body{color:red}")},
+		"app-12345678.js":    &fstest.MapFile{Data: []byte("console.log('app')")},
+	}
+
+	loader, err := NewLoader(fs, "manifest.json", WithBaseURL("https://cdn.example.com/"))
+	if err != nil {
+		t.Fatalf("Failed to create loader: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{
+			name:     "asset",
+			template: `{{asset "app.js"}}`,
+			want:     "/dist/app-12345678.js",
+		},
+		{
+			name:     "asset_url",
+			template: `{{asset_url "app.js"}}`,
+			want:     "https://cdn.example.com/dist/app-12345678.js",
+		},
+		{
+			name:     "integrity from manifest",
+			template: `{{integrity "app.js"}}`,
+			want:     "sha384-app",
+		},
+		{
+			name:     "integrity computed from content",
+			template: `{{integrity "style.css"}}`,
+			// html/template escapes the "+" of base64 in text context
+			want: strings.ReplaceAll(SRI([]byte("body{color:red}")), "+", "&#43;"),
+		},
+		{
+			name:     "script_tag",
+			template: `{{script_tag "app.js" "defer"}}`,
+			want:     `<script defer src="https://cdn.example.com/dist/app-12345678.js" integrity="sha384-app" crossorigin="anonymous"></script>`,
+		},
+		{
+			name:     "script_tag for module",
+			template: `{{script_tag "main.mjs"}}`,
+			want:     `<script type="module" src="https://cdn.example.com/dist/main-12345678.mjs" integrity="sha384-main" crossorigin="anonymous"></script>`,
+		},
+		{
+			name:     "stylesheet_tag",
+			template: `{{stylesheet_tag "style.css"}}`,
+			want:     `<link rel="stylesheet" href="https://cdn.example.com/dist/style-87654321.css" integrity="` + SRI([]byte("body{color:red}")) + `" crossorigin="anonymous">`,
+		},
+		{
+			name:     "preload_tag for font",
+			template: `{{preload_tag "font.woff2"}}`,
+			want:     `<link rel="preload" href="https://cdn.example.com/dist/font-11111111.woff2" as="font" crossorigin="anonymous">`,
+		},
+		{
+			name:     "preload_tag for module",
+			template: `{{preload_tag "main.mjs"}}`,
+			want:     `<link rel="modulepreload" href="https://cdn.example.com/dist/main-12345678.mjs" integrity="sha384-main" crossorigin="anonymous">`,
+		},
+		{
+			name:     "inline_css",
+			template: `<style>{{inline_css "style.css"}}</style>`,
+			want:     `<style>body{color:red}</style>`,
+		},
+		{
+			name:     "inline_js",
+			template: `<script>{{inline_js "app.js"}}</script>`,
+			want:     `<script>console.log('app')</script>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := template.New("test").Funcs(loader.FuncMap()).Parse(tt.template)
+			if err != nil {
+				t.Fatalf("Failed to parse template: %v", err)
+			}
+
+			var b strings.Builder
+			if err := tmpl.Execute(&b, nil); err != nil {
+				t.Fatalf("Failed to execute template: %v", err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("got %s, want %s", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestLoader_FuncMapErrors(t *testing.T) {
+	loader := &Loader{
+		manifest: AssetManifest{
+			Assets: map[string]string{"app.js": "app-12345678.js"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+	}{
+		{name: "unknown script flag", template: `{{script_tag "app.js" "nomodule"}}`},
+		{name: "inline unknown asset", template: `<style>{{inline_css "missing.css"}}</style>`},
+		{name: "inline without filesystem", template: `<script>{{inline_js "app.js"}}</script>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := template.Must(template.New("test").Funcs(loader.FuncMap()).Parse(tt.template))
+			if err := tmpl.Execute(&strings.Builder{}, nil); err == nil {
+				t.Error("Expected error executing template, got nil")
+			}
+		})
+	}
+}
+
+func TestLiveReloadTag(t *testing.T) {
+	want := template.HTML(`<script src="http://localhost:8080/__assetid/livereload.js"></script>`)
+	if got := LiveReloadTag("http://localhost:8080/"); got != want {
+		t.Errorf("LiveReloadTag() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,52 @@
+package assetid
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"time"
+)
+
+// HistoryFile is the name of the build history kept in the output directory
+// when previous builds are retained
+const HistoryFile = "build-history.json"
+
+// BuildHistory records the files written by the builds retained in an output directory, oldest first
+type BuildHistory struct {
+	Builds []BuildRecord `json:"builds"`
+}
+
+// BuildRecord describes the files written by one build
+type BuildRecord struct {
+	Time  time.Time `json:"time"`
+	Files []string  `json:"files"`
+}
+
+// Files returns the set of files written by any build in the history
+func (h BuildHistory) Files() map[string]bool {
+	files := make(map[string]bool)
+	for _, build := range h.Builds {
+		for _, file := range build.Files {
+			files[file] = true
+		}
+	}
+	return files
+}
+
+// ReadHistory decodes the build history at the root of an output directory.
+// A missing history file yields an empty history.
+func ReadHistory(fsys fs.FS) (BuildHistory, error) {
+	data, err := fs.ReadFile(fsys, HistoryFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return BuildHistory{}, nil
+	}
+	if err != nil {
+		return BuildHistory{}, err
+	}
+
+	var history BuildHistory
+	if err := json.Unmarshal(data, &history); err != nil {
+		return BuildHistory{}, err
+	}
+	return history, nil
+}
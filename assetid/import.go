@@ -0,0 +1,178 @@
+package assetid
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ManifestDecoder decodes a manifest file into an AssetManifest
+type ManifestDecoder func(data []byte) (AssetManifest, error)
+
+// decoders are the built-in decoders for each format NewLoader reads
+var decoders = map[ManifestFormat]ManifestDecoder{
+	FormatAssetid:   decodeAssetid,
+	FormatVite:      decodeVite,
+	FormatWebpack:   decodeWebpack,
+	FormatSprockets: decodeSprockets,
+}
+
+// WithFormat makes NewLoader read the manifest in the given format instead of detecting it
+func WithFormat(format ManifestFormat) Option {
+	return func(l *Loader) {
+		decode, ok := decoders[format]
+		if !ok {
+			decode = func([]byte) (AssetManifest, error) {
+				return AssetManifest{}, fmt.Errorf("unsupported manifest format %q", format)
+			}
+		}
+		l.decode = decode
+	}
+}
+
+// WithDecoder makes NewLoader read the manifest with a custom decoder, for formats assetid does not support
+func WithDecoder(decode ManifestDecoder) Option {
+	return func(l *Loader) {
+		l.decode = decode
+	}
+}
+
+// DetectFormat guesses the format of a manifest file from its structure
+func DetectFormat(data []byte) (ManifestFormat, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", fmt.Errorf("manifest is not a JSON object: %w", err)
+	}
+
+	if _, ok := fields["files"]; ok {
+		if _, ok := fields["assets"]; ok {
+			return FormatSprockets, nil
+		}
+	}
+	var assets map[string]string
+	if err := json.Unmarshal(fields["assets"], &assets); err == nil && assets != nil {
+		return FormatAssetid, nil
+	}
+
+	for _, value := range fields {
+		var entry struct {
+			File *string `json:"file"`
+			Src  *string `json:"src"`
+		}
+		var name string
+		switch {
+		case json.Unmarshal(value, &name) == nil:
+			return FormatWebpack, nil
+		case json.Unmarshal(value, &entry) != nil:
+		case entry.File != nil:
+			return FormatVite, nil
+		case entry.Src != nil:
+			return FormatWebpack, nil
+		}
+	}
+	return "", errors.New("unrecognized manifest format")
+}
+
+// decodeAssetid decodes assetid's own manifest.json
+func decodeAssetid(data []byte) (AssetManifest, error) {
+	var manifest AssetManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return AssetManifest{}, err
+	}
+	return manifest, nil
+}
+
+// decodeVite decodes a Vite build manifest, keyed by source path or chunk
+// name. Integrity values are those added by plugins such as vite-plugin-manifest-sri.
+func decodeVite(data []byte) (AssetManifest, error) {
+	var chunks map[string]struct {
+		File      string   `json:"file"`
+		Imports   []string `json:"imports"`
+		Integrity string   `json:"integrity"`
+	}
+	if err := json.Unmarshal(data, &chunks); err != nil {
+		return AssetManifest{}, fmt.Errorf("invalid Vite manifest: %w", err)
+	}
+
+	manifest := newImportedManifest()
+	for name, chunk := range chunks {
+		if chunk.File == "" {
+			return AssetManifest{}, fmt.Errorf("invalid Vite manifest: %s has no file", name)
+		}
+		manifest.Assets[name] = chunk.File
+		if chunk.Integrity != "" {
+			manifest.Integrity[name] = chunk.Integrity
+		}
+		if len(chunk.Imports) > 0 {
+			manifest.Imports[name] = chunk.Imports
+		}
+	}
+	return manifest, nil
+}
+
+// decodeWebpack decodes a webpack-assets-manifest file, either flat or with
+// the {"src", "integrity"} entries written by its integrity option. Its
+// entrypoints section, if any, is skipped.
+func decodeWebpack(data []byte) (AssetManifest, error) {
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return AssetManifest{}, fmt.Errorf("invalid webpack manifest: %w", err)
+	}
+
+	manifest := newImportedManifest()
+	for name, value := range entries {
+		var file string
+		if err := json.Unmarshal(value, &file); err == nil {
+			manifest.Assets[name] = strings.TrimPrefix(file, "/")
+			continue
+		}
+
+		var entry struct {
+			Src       string `json:"src"`
+			Integrity string `json:"integrity"`
+		}
+		if err := json.Unmarshal(value, &entry); err != nil || entry.Src == "" {
+			if name == "entrypoints" {
+				continue
+			}
+			return AssetManifest{}, fmt.Errorf("invalid webpack manifest: unexpected entry %s", name)
+		}
+		manifest.Assets[name] = strings.TrimPrefix(entry.Src, "/")
+		if entry.Integrity != "" {
+			manifest.Integrity[name] = entry.Integrity
+		}
+	}
+	return manifest, nil
+}
+
+// decodeSprockets decodes a Sprockets manifest, taking integrity values from its files section
+func decodeSprockets(data []byte) (AssetManifest, error) {
+	var sprockets struct {
+		Files map[string]struct {
+			Integrity string `json:"integrity"`
+		} `json:"files"`
+		Assets map[string]string `json:"assets"`
+	}
+	if err := json.Unmarshal(data, &sprockets); err != nil {
+		return AssetManifest{}, fmt.Errorf("invalid Sprockets manifest: %w", err)
+	}
+
+	manifest := newImportedManifest()
+	for name, file := range sprockets.Assets {
+		manifest.Assets[name] = file
+		if integrity := sprockets.Files[file].Integrity; integrity != "" {
+			manifest.Integrity[name] = integrity
+		}
+	}
+	return manifest, nil
+}
+
+// newImportedManifest returns an empty manifest for a decoder to fill
+func newImportedManifest() AssetManifest {
+	return AssetManifest{
+		Assets:    make(map[string]string),
+		Integrity: make(map[string]string),
+		Imports:   make(map[string][]string),
+	}
+}
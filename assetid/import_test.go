@@ -0,0 +1,228 @@
+package assetid
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+const viteManifest = `{
+	"src/main.js": {
+		"file": "assets/main-4889e940.js",
+		"src": "src/main.js",
+		"isEntry": true,
+		"imports": ["_shared-83faa3c5.js"],
+		"integrity": "sha384-main"
+	},
+	"src/admin.js": {
+		"file": "assets/admin-2a1f0c3d.js",
+		"src": "src/admin.js",
+		"isEntry": true,
+		"imports": ["src/main.js", "_shared-83faa3c5.js"]
+	},
+	"_shared-83faa3c5.js": {
+		"file": "assets/shared-83faa3c5.js",
+		"imports": ["_vendor-1d2e3f4a.js"]
+	},
+	"_vendor-1d2e3f4a.js": {
+		"file": "assets/vendor-1d2e3f4a.js"
+	}
+}`
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    ManifestFormat
+		wantErr bool
+	}{
+		{name: "assetid", data: `{"assets": {"app.js": "app-1234567890abcdef.js"}, "integrity": {}}`, want: FormatAssetid},
+		{name: "vite", data: viteManifest, want: FormatVite},
+		{name: "webpack flat", data: `{"main.js": "main.abc123.js"}`, want: FormatWebpack},
+		{name: "webpack integrity", data: `{"main.js": {"src": "main.abc123.js", "integrity": "sha384-x"}}`, want: FormatWebpack},
+		{name: "sprockets", data: `{"files": {}, "assets": {}}`, want: FormatSprockets},
+		{name: "not an object", data: `["app.js"]`, wantErr: true},
+		{name: "unrecognized", data: `{"app.js": 1}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectFormat([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DetectFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("DetectFormat() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewLoaderFormats(t *testing.T) {
+	tests := []struct {
+		name          string
+		fsys          fstest.MapFS
+		manifestPath  string
+		opts          []Option
+		wantAssets    map[string]string
+		wantIntegrity map[string]string
+	}{
+		{
+			name: "vite",
+			fsys: fstest.MapFS{
+				".vite/manifest.json": &fstest.MapFile{Data: []byte(viteManifest)},
+			},
+			manifestPath: ".vite/manifest.json",
+			wantAssets: map[string]string{
+				"src/main.js":         "assets/main-4889e940.js",
+				"src/admin.js":        "assets/admin-2a1f0c3d.js",
+				"_shared-83faa3c5.js": "assets/shared-83faa3c5.js",
+				"_vendor-1d2e3f4a.js": "assets/vendor-1d2e3f4a.js",
+			},
+			wantIntegrity: map[string]string{"src/main.js": "sha384-main"},
+		},
+		{
+			name: "webpack flat",
+			fsys: fstest.MapFS{
+				"assets-manifest.json": &fstest.MapFile{Data: []byte(`{"main.js": "/main.abc123.js", "logo.png": "images/logo.def456.png"}`)},
+			},
+			manifestPath:  "assets-manifest.json",
+			wantAssets:    map[string]string{"main.js": "main.abc123.js", "logo.png": "images/logo.def456.png"},
+			wantIntegrity: map[string]string{},
+		},
+		{
+			name: "webpack with integrity and entrypoints",
+			fsys: fstest.MapFS{
+				"assets-manifest.json": &fstest.MapFile{Data: []byte(`{
+					"main.js": {"src": "main.abc123.js", "integrity": "sha384-main"},
+					"entrypoints": {"main": {"assets": {"js": ["main.abc123.js"]}}}
+				}`)},
+			},
+			manifestPath:  "assets-manifest.json",
+			wantAssets:    map[string]string{"main.js": "main.abc123.js"},
+			wantIntegrity: map[string]string{"main.js": "sha384-main"},
+		},
+		{
+			name: "sprockets",
+			fsys: fstest.MapFS{
+				"public/assets/.sprockets-manifest-0123.json": &fstest.MapFile{Data: []byte(`{
+					"files": {"application-d41d8cd9.js": {"logical_path": "application.js", "integrity": "sha256-app"}},
+					"assets": {"application.js": "application-d41d8cd9.js"}
+				}`)},
+			},
+			manifestPath:  "public/assets/.sprockets-manifest-0123.json",
+			wantAssets:    map[string]string{"application.js": "application-d41d8cd9.js"},
+			wantIntegrity: map[string]string{"application.js": "sha256-app"},
+		},
+		{
+			name: "explicit format",
+			fsys: fstest.MapFS{
+				"manifest.json": &fstest.MapFile{Data: []byte(`{"assets": "assets-1234.js"}`)},
+			},
+			manifestPath:  "manifest.json",
+			opts:          []Option{WithFormat(FormatWebpack)},
+			wantAssets:    map[string]string{"assets": "assets-1234.js"},
+			wantIntegrity: map[string]string{},
+		},
+		{
+			name: "custom decoder",
+			fsys: fstest.MapFS{
+				"assets.txt": &fstest.MapFile{Data: []byte("app.js app-1234.js")},
+			},
+			manifestPath: "assets.txt",
+			opts: []Option{WithDecoder(func(data []byte) (AssetManifest, error) {
+				return AssetManifest{Assets: map[string]string{"app.js": "app-1234.js"}}, nil
+			})},
+			wantAssets: map[string]string{"app.js": "app-1234.js"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader, err := NewLoader(tt.fsys, tt.manifestPath, tt.opts...)
+			if err != nil {
+				t.Fatalf("NewLoader failed: %v", err)
+			}
+			if !maps.Equal(loader.manifest.Assets, tt.wantAssets) {
+				t.Errorf("assets = %v, want %v", loader.manifest.Assets, tt.wantAssets)
+			}
+			if !maps.Equal(loader.manifest.Integrity, tt.wantIntegrity) {
+				t.Errorf("integrity = %v, want %v", loader.manifest.Integrity, tt.wantIntegrity)
+			}
+		})
+	}
+}
+
+func TestNewLoaderFormatErrors(t *testing.T) {
+	fsys := fstest.MapFS{
+		"manifest.json": &fstest.MapFile{Data: []byte(`{"assets": {"app.js": "app-1234567890abcdef.js"}}`)},
+		"vite.json":     &fstest.MapFile{Data: []byte(`{"src/main.js": {"src": "src/main.js"}}`)},
+	}
+
+	tests := []struct {
+		name         string
+		manifestPath string
+		opts         []Option
+	}{
+		{name: "unsupported format", manifestPath: "manifest.json", opts: []Option{WithFormat(FormatMix)}},
+		{name: "wrong explicit format", manifestPath: "manifest.json", opts: []Option{WithFormat(FormatVite)}},
+		{name: "Vite chunk without file", manifestPath: "vite.json", opts: []Option{WithFormat(FormatVite)}},
+		{
+			name:         "decoder error",
+			manifestPath: "manifest.json",
+			opts: []Option{WithDecoder(func([]byte) (AssetManifest, error) {
+				return AssetManifest{}, errors.New("boom")
+			})},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewLoader(fsys, tt.manifestPath, tt.opts...); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestLoader_ViteAssets(t *testing.T) {
+	fsys := fstest.MapFS{
+		"dist/.vite/manifest.json":       &fstest.MapFile{Data: []byte(viteManifest)},
+		"dist/assets/main-4889e940.js":   &fstest.MapFile{Data: []byte("import './shared-83faa3c5.js'")},
+		"dist/assets/shared-83faa3c5.js": &fstest.MapFile{Data: []byte("export {}")},
+		"dist/assets/vendor-1d2e3f4a.js": &fstest.MapFile{Data: []byte("export {}")},
+		"dist/assets/admin-2a1f0c3d.js":  &fstest.MapFile{Data: []byte("import './main-4889e940.js'")},
+	}
+
+	loader, err := NewLoader(fsys, "dist/.vite/manifest.json")
+	if err != nil {
+		t.Fatalf("NewLoader failed: %v", err)
+	}
+
+	if got := loader.Path("src/main.js"); got != "/dist/assets/main-4889e940.js" {
+		t.Errorf("Path() = %s", got)
+	}
+	// files are read relative to the output directory, not .vite/
+	if content, err := loader.ReadFile("src/main.js"); err != nil || string(content) != "import './shared-83faa3c5.js'" {
+		t.Errorf("ReadFile() = %q, %v", content, err)
+	}
+
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{name: "src/main.js", want: []string{"_shared-83faa3c5.js", "_vendor-1d2e3f4a.js"}},
+		{name: "src/admin.js", want: []string{"src/main.js", "_shared-83faa3c5.js", "_vendor-1d2e3f4a.js"}},
+		{name: "_vendor-1d2e3f4a.js", want: nil},
+		{name: "missing.js", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := loader.Imports(tt.name); !slices.Equal(got, tt.want) {
+				t.Errorf("Imports(%s) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
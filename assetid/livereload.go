@@ -0,0 +1,21 @@
+package assetid
+
+import (
+	"html/template"
+	"strings"
+)
+
+// LiveReloadPath is the path of the live reload script served by `assetid serve`
+const LiveReloadPath = "/__assetid/livereload.js"
+
+// LiveReloadTag returns a script element that connects a page to the live
+// reload endpoint of `assetid serve` running at origin, e.g.
+// "http://localhost:8080". It is meant for development templates served by
+// the application itself rather than by `assetid serve`.
+func LiveReloadTag(origin string) template.HTML {
+	var b strings.Builder
+	b.WriteString("<script")
+	writeAttr(&b, "src", strings.TrimSuffix(origin, "/")+LiveReloadPath)
+	b.WriteString("></script>")
+	return template.HTML(b.String())
+}
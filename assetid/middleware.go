@@ -0,0 +1,134 @@
+package assetid
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// UnhashedMode selects how requests for unhashed asset URLs are answered
+type UnhashedMode int
+
+const (
+	// RedirectUnhashed answers with a 302 to the fingerprinted URL
+	RedirectUnhashed UnhashedMode = iota
+	// ServeUnhashed serves the current content of the asset without caching
+	ServeUnhashed
+)
+
+const (
+	// redirectCacheControl lets clients reuse a redirect briefly without pinning an old build
+	redirectCacheControl = "public, max-age=300"
+	// immutableCacheControl lets clients cache fingerprinted files forever since their names change with their content
+	immutableCacheControl = "public, max-age=31536000, immutable"
+)
+
+// Handler returns an http.Handler serving the files named in the manifest
+// under /dist/. Fingerprinted files are served with a far-future immutable
+// cache lifetime, files emitted under their original name with no-cache, and
+// anything else is not found. Development loaders serve every file with no-cache.
+func (l *Loader) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fingerprinted, ok := strings.CutPrefix(r.URL.Path, urlPrefix+"/")
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		name, ok := l.Original(fingerprinted)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		cacheControl := immutableCacheControl
+		if name == fingerprinted || l.dev != nil {
+			cacheControl = "no-cache"
+		}
+		l.serveFile(w, r, l.file(name, fingerprinted), cacheControl)
+	})
+}
+
+// UnhashedMiddleware returns middleware that answers requests for an asset's
+// original URL (e.g. /dist/app.js) with its fingerprinted version, so stale
+// HTML and bookmarked links keep working after a rebuild. Requests that do not
+// name an original asset in the manifest are passed through to next.
+func (l *Loader) UnhashedMiddleware(mode UnhashedMode) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			name, fingerprinted, ok := l.unhashed(r.URL.Path)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if mode == ServeUnhashed {
+				l.serveFile(w, r, l.file(name, fingerprinted), "no-cache")
+				return
+			}
+
+			target := l.Path(name)
+			if r.URL.RawQuery != "" {
+				target += "?" + r.URL.RawQuery
+			}
+			w.Header().Set("Cache-Control", redirectCacheControl)
+			http.Redirect(w, r, target, http.StatusFound)
+		})
+	}
+}
+
+// unhashed reports whether urlPath is the unhashed URL of an asset in the
+// manifest, returning its original and fingerprinted names
+func (l *Loader) unhashed(urlPath string) (string, string, bool) {
+	name, ok := strings.CutPrefix(urlPath, urlPrefix+"/")
+	if !ok {
+		return "", "", false
+	}
+
+	fingerprinted, ok := l.lookup(name)
+	if !ok || fingerprinted == name {
+		return "", "", false
+	}
+	return name, fingerprinted, true
+}
+
+// serveFile writes the content of a file from the output directory to w,
+// setting cacheControl only once the file is known to exist
+func (l *Loader) serveFile(w http.ResponseWriter, r *http.Request, name, cacheControl string) {
+	if l.fsys == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	file, err := l.fsys.Open(name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	content, ok := file.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		content = bytes.NewReader(data)
+	}
+
+	w.Header().Set("Cache-Control", cacheControl)
+	http.ServeContent(w, r, name, info.ModTime(), content)
+}
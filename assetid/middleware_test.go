@@ -0,0 +1,192 @@
+package assetid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestUnhashedMiddleware(t *testing.T) {
+	fs := fstest.MapFS{
+		"manifest.json": &fstest.MapFile{
+			Data: []byte(`{"assets":{"app.js":"app-12345678.js","index.html":"index.html"}}`),
+		},
+		"app-12345678.js": &fstest.MapFile{
+			Data: []byte("console.log('app');"),
+		},
+	}
+
+	loader, err := NewLoader(fs, "manifest.json")
+	if err != nil {
+		t.Fatalf("Failed to create loader: %v", err)
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	tests := []struct {
+		name         string
+		mode         UnhashedMode
+		method       string
+		target       string
+		wantStatus   int
+		wantLocation string
+		wantCache    string
+		wantBody     string
+	}{
+		{
+			name:         "redirects unhashed asset",
+			mode:         RedirectUnhashed,
+			method:       http.MethodGet,
+			target:       "/dist/app.js",
+			wantStatus:   http.StatusFound,
+			wantLocation: "/dist/app-12345678.js",
+			wantCache:    redirectCacheControl,
+		},
+		{
+			name:         "redirect keeps query string",
+			mode:         RedirectUnhashed,
+			method:       http.MethodGet,
+			target:       "/dist/app.js?v=1",
+			wantStatus:   http.StatusFound,
+			wantLocation: "/dist/app-12345678.js?v=1",
+			wantCache:    redirectCacheControl,
+		},
+		{
+			name:       "serves unhashed asset",
+			mode:       ServeUnhashed,
+			method:     http.MethodGet,
+			target:     "/dist/app.js",
+			wantStatus: http.StatusOK,
+			wantCache:  "no-cache",
+			wantBody:   "console.log('app');",
+		},
+		{
+			name:       "passes through fingerprinted asset",
+			mode:       RedirectUnhashed,
+			method:     http.MethodGet,
+			target:     "/dist/app-12345678.js",
+			wantStatus: http.StatusTeapot,
+		},
+		{
+			name:       "passes through asset with stable name",
+			mode:       RedirectUnhashed,
+			method:     http.MethodGet,
+			target:     "/dist/index.html",
+			wantStatus: http.StatusTeapot,
+		},
+		{
+			name:       "passes through unknown asset",
+			mode:       ServeUnhashed,
+			method:     http.MethodGet,
+			target:     "/dist/unknown.js",
+			wantStatus: http.StatusTeapot,
+		},
+		{
+			name:       "passes through other methods",
+			mode:       RedirectUnhashed,
+			method:     http.MethodPost,
+			target:     "/dist/app.js",
+			wantStatus: http.StatusTeapot,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := loader.UnhashedMiddleware(tt.mode)(next)
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", got, tt.wantLocation)
+			}
+			if got := rec.Header().Get("Cache-Control"); got != tt.wantCache {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.wantCache)
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestLoader_Handler(t *testing.T) {
+	fs := fstest.MapFS{
+		"manifest.json": &fstest.MapFile{
+			Data: []byte(`{"assets":{"app.js":"app-12345678.js","index.html":"index.html","gone.js":"gone-12345678.js"}}`),
+		},
+		"app-12345678.js": &fstest.MapFile{Data: []byte("console.log('app');")},
+		"index.html":      &fstest.MapFile{Data: []byte("<h1>hi</h1>")},
+	}
+
+	loader, err := NewFSLoader(fs)
+	if err != nil {
+		t.Fatalf("Failed to create loader: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantCache  string
+		wantBody   string
+	}{
+		{
+			name:       "fingerprinted asset",
+			target:     "/dist/app-12345678.js",
+			wantStatus: http.StatusOK,
+			wantCache:  immutableCacheControl,
+			wantBody:   "console.log('app');",
+		},
+		{
+			name:       "asset with stable name",
+			target:     "/dist/index.html",
+			wantStatus: http.StatusOK,
+			wantCache:  "no-cache",
+			wantBody:   "<h1>hi</h1>",
+		},
+		{
+			name:       "original name",
+			target:     "/dist/app.js",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "manifest",
+			target:     "/dist/manifest.json",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "missing file",
+			target:     "/dist/gone-12345678.js",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "outside prefix",
+			target:     "/app-12345678.js",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			loader.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Cache-Control"); got != tt.wantCache {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.wantCache)
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package assetid
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/jm96441n/assetid/internal/htmlrewrite"
+)
+
+// Minifier returns the minified content of the file at name, a slash-separated
+// path, or content unchanged if it does not minify that kind of file
+type Minifier func(name string, content []byte) ([]byte, error)
+
+// errMinifierUnsupported is returned by loaders other than NewRuntimeLoader given WithMinifier
+var errMinifierUnsupported = errors.New("WithMinifier is only supported by NewRuntimeLoader")
+
+// WithMinifier makes NewRuntimeLoader minify files in memory with minify
+// before fingerprinting them. build.Config.Minifier returns the minifier of a
+// build, so that files get the names the build gives them. Other loaders serve
+// files that are already built and return errMinifierUnsupported.
+func WithMinifier(minify Minifier) Option {
+	return func(l *Loader) {
+		l.minify = minify
+	}
+}
+
+// NewRuntimeLoader fingerprints every file in fsys once at startup, for
+// services that embed unprocessed static files instead of running a build.
+// Files are named from their final content exactly as a build names them, so
+// a build minifying with the same configuration as WithMinifier produces the
+// same names, and they are served from memory through the usual Loader and
+// Handler APIs. HTML pages keep their names and have their asset references
+// rewritten, as a build without HTML integrity attributes does.
+func NewRuntimeLoader(fsys fs.FS, opts ...Option) (*Loader, error) {
+	loader := &Loader{}
+	for _, opt := range opts {
+		opt(loader)
+	}
+
+	manifest := AssetManifest{
+		Assets:    make(map[string]string),
+		Integrity: make(map[string]string),
+	}
+	files := &memFS{
+		files:   make(map[string][]byte),
+		modTime: time.Now(),
+	}
+
+	// pages are rewritten once every asset they may reference is fingerprinted
+	var pages []string
+	err := fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		if htmlrewrite.IsPage(name) {
+			pages = append(pages, name)
+			return nil
+		}
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if content, err = loader.minifyFile(name, content); err != nil {
+			return err
+		}
+
+		hash, err := Hash(bytes.NewReader(content))
+		if err != nil {
+			return fmt.Errorf("failed to calculate hash for %s: %w", name, err)
+		}
+
+		fingerprinted := FingerprintedName(name, hash)
+		files.files[fingerprinted] = content
+		manifest.Assets[name] = fingerprinted
+		manifest.Integrity[name] = SRI(content)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fingerprint assets: %w", err)
+	}
+
+	rewriter := &htmlrewrite.Rewriter{Assets: manifest.Assets, Integrity: manifest.Integrity}
+	for _, name := range pages {
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to rewrite pages: failed to read %s: %w", name, err)
+		}
+		if content, err = rewriter.Rewrite(name, content); err != nil {
+			return nil, fmt.Errorf("failed to rewrite pages: %w", err)
+		}
+		if content, err = loader.minifyFile(name, content); err != nil {
+			return nil, fmt.Errorf("failed to rewrite pages: %w", err)
+		}
+
+		files.files[name] = content
+		manifest.Assets[name] = name
+		manifest.Integrity[name] = SRI(content)
+	}
+
+	loader.manifest = manifest
+	loader.fsys = files
+	return loader, nil
+}
+
+// minifyFile returns the content of the file at name minified, if the loader
+// has a minifier
+func (l *Loader) minifyFile(name string, content []byte) ([]byte, error) {
+	if l.minify == nil {
+		return content, nil
+	}
+	content, err := l.minify(name, content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to minify %s: %w", name, err)
+	}
+	return content, nil
+}
@@ -0,0 +1,128 @@
+package assetid
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// collapseScripts is a minifier removing the whitespace of JS and CSS files
+func collapseScripts(name string, content []byte) ([]byte, error) {
+	if !strings.HasSuffix(name, ".js") && !strings.HasSuffix(name, ".css") {
+		return content, nil
+	}
+	return bytes.Join(bytes.Fields(content), nil), nil
+}
+
+func TestNewRuntimeLoader(t *testing.T) {
+	jsSource := `
+		// greet the user
+		function greet(name) {
+			return "Hello, " + name;
+		}
+	`
+	source := fstest.MapFS{
+		"app.js":       &fstest.MapFile{Data: []byte(jsSource)},
+		"css/site.css": &fstest.MapFile{Data: []byte("body {\n  color: #ff0000;\n}\n")},
+		"img/logo.svg": &fstest.MapFile{Data: []byte("<svg></svg>")},
+	}
+
+	tests := []struct {
+		name   string
+		opts   []Option
+		minify bool
+	}{
+		{name: "without minification"},
+		{name: "with minification", opts: []Option{WithMinifier(collapseScripts)}, minify: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader, err := NewRuntimeLoader(source, tt.opts...)
+			if err != nil {
+				t.Fatalf("NewRuntimeLoader failed: %v", err)
+			}
+
+			for name, file := range source {
+				content, err := loader.ReadFile(name)
+				if err != nil {
+					t.Fatalf("ReadFile(%q) failed: %v", name, err)
+				}
+
+				hash, err := Hash(strings.NewReader(string(content)))
+				if err != nil {
+					t.Fatalf("Hash failed: %v", err)
+				}
+				if got, want := loader.Path(name), "/dist/"+FingerprintedName(name, hash); got != want {
+					t.Errorf("Loader.Path(%q) = %v, want %v", name, got, want)
+				}
+				if got, want := loader.Integrity(name), SRI(content); got != want {
+					t.Errorf("Loader.Integrity(%q) = %v, want %v", name, got, want)
+				}
+
+				minified := len(content) < len(file.Data)
+				if name != "img/logo.svg" && minified != tt.minify {
+					t.Errorf("%s minified = %v, want %v", name, minified, tt.minify)
+				}
+				if name == "img/logo.svg" && string(content) != string(file.Data) {
+					t.Errorf("%s was modified: %q", name, content)
+				}
+			}
+
+			rec := httptest.NewRecorder()
+			loader.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, loader.Path("app.js"), nil))
+			if rec.Code != http.StatusOK {
+				t.Errorf("Handler() status = %d, want %d", rec.Code, http.StatusOK)
+			}
+			if got := rec.Header().Get("Cache-Control"); got != immutableCacheControl {
+				t.Errorf("Cache-Control = %q, want %q", got, immutableCacheControl)
+			}
+		})
+	}
+}
+
+func TestNewRuntimeLoaderMinifyError(t *testing.T) {
+	source := fstest.MapFS{
+		"broken.js": &fstest.MapFile{Data: []byte("function (")},
+	}
+	errSyntax := errors.New("unexpected (")
+	failing := func(name string, content []byte) ([]byte, error) {
+		return nil, errSyntax
+	}
+
+	if _, err := NewRuntimeLoader(source, WithMinifier(failing)); !errors.Is(err, errSyntax) {
+		t.Errorf("NewRuntimeLoader error = %v, want %v", err, errSyntax)
+	}
+	if _, err := NewRuntimeLoader(source); err != nil {
+		t.Errorf("Unexpected error without minification: %v", err)
+	}
+}
+
+func TestWithMinifierUnsupported(t *testing.T) {
+	output := fstest.MapFS{
+		ManifestFile: &fstest.MapFile{Data: []byte(`{"assets": {}}`)},
+	}
+
+	tests := []struct {
+		name string
+		load func() (*Loader, error)
+	}{
+		{name: "NewLoader", load: func() (*Loader, error) {
+			return NewLoader(output, ManifestFile, WithMinifier(collapseScripts))
+		}},
+		{name: "NewFSLoader", load: func() (*Loader, error) { return NewFSLoader(output, WithMinifier(collapseScripts)) }},
+		{name: "NewDevLoader", load: func() (*Loader, error) { return NewDevLoader(t.TempDir(), WithMinifier(collapseScripts)) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.load(); !errors.Is(err, errMinifierUnsupported) {
+				t.Errorf("%s error = %v, want %v", tt.name, err, errMinifierUnsupported)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package assetid
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"slices"
+)
+
+// VerifyReport describes how an output directory differs from its manifest
+type VerifyReport struct {
+	// Checked is the number of manifest entries that were checked
+	Checked int `json:"checked"`
+	// Incomplete reports that the manifest was written by a build in which some files failed
+	Incomplete bool `json:"incomplete,omitempty"`
+	// Missing lists manifest entries whose file does not exist
+	Missing []VerifyProblem `json:"missing,omitempty"`
+	// HashMismatches lists files whose content hash differs from the fingerprint in their name
+	HashMismatches []VerifyProblem `json:"hashMismatches,omitempty"`
+	// IntegrityMismatches lists files whose content differs from the manifest's integrity value
+	IntegrityMismatches []VerifyProblem `json:"integrityMismatches,omitempty"`
+	// Orphans lists fingerprinted files that no manifest entry refers to
+	Orphans []string `json:"orphans,omitempty"`
+}
+
+// VerifyProblem describes one inconsistency found by Verify
+type VerifyProblem struct {
+	Asset    string `json:"asset"`
+	File     string `json:"file"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+}
+
+// OK reports whether no problems were found
+func (r *VerifyReport) OK() bool {
+	return !r.Incomplete &&
+		len(r.Missing) == 0 &&
+		len(r.HashMismatches) == 0 &&
+		len(r.IntegrityMismatches) == 0 &&
+		len(r.Orphans) == 0
+}
+
+// Problems returns the total number of problems found
+func (r *VerifyReport) Problems() int {
+	problems := len(r.Missing) + len(r.HashMismatches) + len(r.IntegrityMismatches) + len(r.Orphans)
+	if r.Incomplete {
+		problems++
+	}
+	return problems
+}
+
+// Verify checks an output directory against the manifest at its root: every
+// entry must name an existing file whose content hash matches the fingerprint
+// in its name and whose content matches its integrity value, and every
+// fingerprinted file must be referenced by the manifest or by a build retained
+// in the build history. Files emitted under their original name are exempt
+// from the hash check. A manifest marked incomplete is a problem too. An error
+// is returned only if the manifest or directory cannot be read.
+func Verify(fsys fs.FS) (*VerifyReport, error) {
+	manifest, err := ReadManifest(fsys, ManifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	// files from retained builds are kept deliberately and are not orphans
+	history, err := ReadHistory(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read build history: %w", err)
+	}
+
+	report := &VerifyReport{Incomplete: manifest.Incomplete}
+	referenced := history.Files()
+
+	names := make([]string, 0, len(manifest.Assets))
+	for name := range manifest.Assets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		fingerprinted := manifest.Assets[name]
+		referenced[fingerprinted] = true
+		report.Checked++
+
+		content, err := fs.ReadFile(fsys, fingerprinted)
+		if err != nil {
+			report.Missing = append(report.Missing, VerifyProblem{Asset: name, File: fingerprinted})
+			continue
+		}
+
+		if _, expected, ok := splitFingerprint(fingerprinted); ok && fingerprinted != name {
+			actual, err := Hash(bytes.NewReader(content))
+			if err != nil {
+				return nil, fmt.Errorf("failed to calculate hash for %s: %w", fingerprinted, err)
+			}
+			if actual != expected {
+				report.HashMismatches = append(report.HashMismatches, VerifyProblem{
+					Asset: name, File: fingerprinted, Expected: expected, Actual: actual,
+				})
+			}
+		}
+
+		if expected, ok := manifest.Integrity[name]; ok {
+			if actual := SRI(content); actual != expected {
+				report.IntegrityMismatches = append(report.IntegrityMismatches, VerifyProblem{
+					Asset: name, File: fingerprinted, Expected: expected, Actual: actual,
+				})
+			}
+		}
+	}
+
+	err = fs.WalkDir(fsys, ".", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || referenced[path] {
+			return nil
+		}
+		if _, _, ok := splitFingerprint(path); ok {
+			report.Orphans = append(report.Orphans, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan output directory: %w", err)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,153 @@
+package assetid
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// newVerifyFS returns an output directory with a consistent manifest for app.js and index.html
+func newVerifyFS(t *testing.T) fstest.MapFS {
+	t.Helper()
+
+	appJS := []byte("console.log('app');")
+	hash, err := Hash(strings.NewReader(string(appJS)))
+	if err != nil {
+		t.Fatalf("Hash failed: %v", err)
+	}
+	index := []byte("<h1>hi</h1>")
+
+	manifest, err := json.Marshal(AssetManifest{
+		Assets: map[string]string{
+			"app.js":     FingerprintedName("app.js", hash),
+			"index.html": "index.html",
+		},
+		Integrity: map[string]string{
+			"app.js":     SRI(appJS),
+			"index.html": SRI(index),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal manifest: %v", err)
+	}
+
+	return fstest.MapFS{
+		"manifest.json":                   &fstest.MapFile{Data: manifest},
+		FingerprintedName("app.js", hash): &fstest.MapFile{Data: appJS},
+		"index.html":                      &fstest.MapFile{Data: index},
+		"robots.txt":                      &fstest.MapFile{Data: []byte("unreferenced but not fingerprinted")},
+	}
+}
+
+func TestVerify(t *testing.T) {
+	appFile := func(fsys fstest.MapFS) string {
+		for name := range fsys {
+			if strings.HasPrefix(name, "app-") {
+				return name
+			}
+		}
+		t.Fatal("app.js not found in test filesystem")
+		return ""
+	}
+
+	tests := []struct {
+		name   string
+		modify func(fsys fstest.MapFS)
+		check  func(t *testing.T, report *VerifyReport)
+	}{
+		{
+			name:   "consistent output",
+			modify: func(fsys fstest.MapFS) {},
+			check: func(t *testing.T, report *VerifyReport) {
+				if !report.OK() || report.Checked != 2 {
+					t.Errorf("Expected OK report for 2 assets, got %+v", report)
+				}
+			},
+		},
+		{
+			name: "missing file",
+			modify: func(fsys fstest.MapFS) {
+				delete(fsys, appFile(fsys))
+			},
+			check: func(t *testing.T, report *VerifyReport) {
+				if len(report.Missing) != 1 || report.Missing[0].Asset != "app.js" {
+					t.Errorf("Expected app.js to be missing, got %+v", report)
+				}
+			},
+		},
+		{
+			name: "modified content",
+			modify: func(fsys fstest.MapFS) {
+				fsys[appFile(fsys)].Data = []byte("tampered")
+			},
+			check: func(t *testing.T, report *VerifyReport) {
+				if len(report.HashMismatches) != 1 || len(report.IntegrityMismatches) != 1 {
+					t.Errorf("Expected hash and integrity mismatch, got %+v", report)
+				}
+				if report.Problems() != 2 {
+					t.Errorf("Problems() = %d, want 2", report.Problems())
+				}
+			},
+		},
+		{
+			name: "modified file with stable name",
+			modify: func(fsys fstest.MapFS) {
+				fsys["index.html"].Data = []byte("<h1>changed</h1>")
+			},
+			check: func(t *testing.T, report *VerifyReport) {
+				if len(report.HashMismatches) != 0 || len(report.IntegrityMismatches) != 1 {
+					t.Errorf("Expected only an integrity mismatch, got %+v", report)
+				}
+			},
+		},
+		{
+			name: "orphaned fingerprinted file",
+			modify: func(fsys fstest.MapFS) {
+				fsys["old/app-0123456789abcdef.js"] = &fstest.MapFile{Data: []byte("old")}
+			},
+			check: func(t *testing.T, report *VerifyReport) {
+				if len(report.Orphans) != 1 || report.Orphans[0] != "old/app-0123456789abcdef.js" {
+					t.Errorf("Expected one orphan, got %+v", report)
+				}
+			},
+		},
+		{
+			name: "incomplete manifest",
+			modify: func(fsys fstest.MapFS) {
+				var manifest AssetManifest
+				if err := json.Unmarshal(fsys["manifest.json"].Data, &manifest); err != nil {
+					t.Fatalf("Failed to parse manifest: %v", err)
+				}
+				manifest.Incomplete = true
+				data, err := json.Marshal(manifest)
+				if err != nil {
+					t.Fatalf("Failed to marshal manifest: %v", err)
+				}
+				fsys["manifest.json"].Data = data
+			},
+			check: func(t *testing.T, report *VerifyReport) {
+				if report.OK() || !report.Incomplete || report.Problems() != 1 {
+					t.Errorf("Expected only an incomplete manifest, got %+v", report)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := newVerifyFS(t)
+			tt.modify(fsys)
+
+			report, err := Verify(fsys)
+			if err != nil {
+				t.Fatalf("Verify failed: %v", err)
+			}
+			tt.check(t, report)
+		})
+	}
+
+	if _, err := Verify(fstest.MapFS{}); err == nil {
+		t.Error("Expected error for missing manifest, got nil")
+	}
+}
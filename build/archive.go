@@ -0,0 +1,125 @@
+package build
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"time"
+)
+
+// archiveTime is the modification time of every archive entry, so archives of
+// the same build are identical
+var archiveTime = time.Unix(0, 0).UTC()
+
+// TarOutput writes the files of a build to a tar archive. Close must be
+// called once the build is done to finish the archive.
+type TarOutput struct {
+	tw *tar.Writer
+}
+
+// Tar returns an output writing a tar archive to w
+func Tar(w io.Writer) *TarOutput {
+	return &TarOutput{tw: tar.NewWriter(w)}
+}
+
+// WriteFile adds a file to the archive
+func (t *TarOutput) WriteFile(name string, data []byte) error {
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		ModTime:  archiveTime,
+		Format:   tar.FormatPAX,
+	}
+	if err := t.tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to add %s to archive: %w", name, err)
+	}
+	if _, err := t.tw.Write(data); err != nil {
+		return fmt.Errorf("failed to add %s to archive: %w", name, err)
+	}
+	return nil
+}
+
+// Close finishes the archive without closing the underlying writer
+func (t *TarOutput) Close() error {
+	return t.tw.Close()
+}
+
+// ZipOutput writes the files of a build to a zip archive. Close must be
+// called once the build is done to finish the archive.
+type ZipOutput struct {
+	zw *zip.Writer
+}
+
+// Zip returns an output writing a zip archive to w
+func Zip(w io.Writer) *ZipOutput {
+	return &ZipOutput{zw: zip.NewWriter(w)}
+}
+
+// WriteFile adds a compressed file to the archive
+func (z *ZipOutput) WriteFile(name string, data []byte) error {
+	w, err := z.zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: archiveTime})
+	if err != nil {
+		return fmt.Errorf("failed to add %s to archive: %w", name, err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to add %s to archive: %w", name, err)
+	}
+	return nil
+}
+
+// Close finishes the archive without closing the underlying writer
+func (z *ZipOutput) Close() error {
+	return z.zw.Close()
+}
+
+// ContentAddressedIndex is the file ContentAddressedOutput writes its index to
+const ContentAddressedIndex = "index.json"
+
+// ContentAddressedOutput stores each file in another output under the SHA-256
+// of its content, at objects/<2 hex digits>/<62 hex digits><ext>, so that
+// identical files share an object and objects written by different builds
+// never conflict, e.g. in a blob store. Close writes the index of names to objects.
+type ContentAddressedOutput struct {
+	out Output
+	// Index maps the name of every file written to the path of its object
+	Index   map[string]string
+	objects map[string]bool
+}
+
+// ContentAddressed returns an output storing objects in out
+func ContentAddressed(out Output) *ContentAddressedOutput {
+	return &ContentAddressedOutput{out: out, Index: make(map[string]string), objects: make(map[string]bool)}
+}
+
+// WriteFile stores data as an object, unless an identical object was already
+// written by this output, and records it in the index under name
+func (c *ContentAddressedOutput) WriteFile(name string, data []byte) error {
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+	object := path.Join("objects", digest[:2], digest[2:]+path.Ext(name))
+
+	if !c.objects[object] {
+		if err := c.out.WriteFile(object, data); err != nil {
+			return err
+		}
+		c.objects[object] = true
+	}
+	c.Index[name] = object
+	return nil
+}
+
+// Close writes the index to ContentAddressedIndex in the underlying output
+func (c *ContentAddressedOutput) Close() error {
+	data, err := json.MarshalIndent(c.Index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode index: %w", err)
+	}
+	return c.out.WriteFile(ContentAddressedIndex, append(data, '\n'))
+}
@@ -0,0 +1,508 @@
+// Package build fingerprints the assets of a source directory, writes them to
+// an output directory with their manifest, and runs every processing step in
+// between: external commands, custom transformers, minification, license
+// collection, banners and HTML rewriting. It is what the assetid command runs,
+// exposed for Go tooling and tests.
+//
+// New fields may be added to Options, Result, Stats and Event in minor
+// versions; their zero values keep the previous behavior.
+package build
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jm96441n/assetid/assetid"
+	"github.com/jm96441n/assetid/internal/htmlrewrite"
+	"github.com/jm96441n/assetid/transform"
+)
+
+// Options configures a build
+type Options struct {
+	// SourceDir is the directory containing the assets. External commands run
+	// from it.
+	SourceDir string
+	// Source is the file system the assets are read from; nil reads SourceDir
+	Source fs.FS
+	// OutputDir is the directory the assets and manifest are written to. It is
+	// removed first unless Retention keeps previous builds.
+	OutputDir string
+	// Output receives the files written; nil writes to OutputDir
+	Output Output
+	// Minify minifies JS, HTML, SVG, JSON and XML files, and CSS if enabled in Config
+	Minify bool
+	// Config holds the minifier options, banner, license file and external
+	// commands; nil uses DefaultConfig
+	Config *Config
+	// Retention keeps files from previous builds in the output, which must be a directory
+	Retention Retention
+	// Atomic builds a directory output in a staging directory next to it and
+	// only replaces the output with it once the build succeeds, so a failed or
+	// interrupted build keeps the previous output. Without it, a failed build
+	// removes the files it wrote, and files of retained builds it would have
+	// replaced are left unchanged. It cannot be combined with Retention.
+	Atomic bool
+	// KeepGoing records the failure of a file and carries on with the others
+	// instead of stopping the build. The manifest then lists the files that
+	// were built and is marked Incomplete, and Build returns the result with an
+	// *IncompleteError listing every failure. With Atomic, the previous output
+	// is kept instead.
+	KeepGoing bool
+	// Exports are the manifest formats written alongside manifest.json
+	Exports []assetid.ManifestFormat
+	// Version is the version of the build, available to the banner as {{.Version}}
+	Version string
+	// HTMLIntegrity adds integrity and crossorigin attributes to scripts and
+	// stylesheets referenced by HTML pages
+	HTMLIntegrity bool
+	// Transformers run on matching files after the configured commands and
+	// before the built-in steps
+	Transformers []Transformer
+	// OnEvent, if set, is called for every file written, in order
+	OnEvent func(Event)
+}
+
+// Transformer is a transformer added to a build for the files Match accepts
+type Transformer struct {
+	Match       transform.Matcher
+	Transformer transform.Transformer
+}
+
+// EventKind identifies what happened to a file during a build
+type EventKind int
+
+const (
+	// AssetWritten reports a file written under its fingerprinted name
+	AssetWritten EventKind = iota
+	// PageWritten reports an HTML page written under its original name
+	PageWritten
+)
+
+// Event reports a file written by a build
+type Event struct {
+	Kind EventKind
+	// Path is the file's path relative to the source directory; for the
+	// license file it is the name the file is listed under in the manifest
+	Path string
+	// Name is the name the file is listed under in the manifest, which differs
+	// from Path if a transformer changed its extension
+	Name string
+	// Output is the path the file was written to, relative to the output directory
+	Output string
+	// Size is the number of bytes written
+	Size int64
+	// Dependencies lists the other files the transformers built the file
+	// from, such as imported partials, relative to the source directory
+	Dependencies []string
+}
+
+// Result describes a finished build
+type Result struct {
+	// Manifest is the manifest written to the output directory
+	Manifest assetid.AssetManifest
+	Stats    Stats
+	// Warnings are problems that did not fail the build
+	Warnings []Warning
+	// Dependencies maps the slash-separated path of each file written to the
+	// other files the transformers built it from, for files that have any
+	Dependencies map[string][]string
+}
+
+// Stats counts the work done by a build
+type Stats struct {
+	// Assets is the number of fingerprinted files written, including the license file
+	Assets int
+	// Pages is the number of HTML pages written
+	Pages int
+	// SourceBytes is the size of the source files read
+	SourceBytes int64
+	// OutputBytes is the size of the assets and pages written
+	OutputBytes int64
+	// Duration is how long the build took
+	Duration time.Duration
+}
+
+// Warning is a problem found in a file that did not fail the build
+type Warning struct {
+	// Path is the file's path relative to the source directory
+	Path    string
+	Message string
+}
+
+// String returns the warning prefixed with its file
+func (w Warning) String() string {
+	return w.Path + ": " + w.Message
+}
+
+// builder holds the state of a build in progress
+type builder struct {
+	opts   Options
+	config Config
+	source fs.FS
+	out    Output
+	// dir is the directory output, if any, and staging the directory an atomic build writes to
+	dir     *DirOutput
+	staging *DirOutput
+	written writtenFS
+	start   time.Time
+	// commands runs the configured commands, ahead of registry, and commanded
+	// holds their results for the assets they ran on in parallel
+	commands  *transform.Registry
+	commanded map[string]commandResult
+	registry  *transform.Registry
+	licenses  *licenseBundle
+	manifest  assetid.AssetManifest
+	result    *Result
+	// failures are the files that failed in a KeepGoing build
+	failures []FileError
+}
+
+// Build runs a build with the given options. It stops between files and
+// transformers once ctx is done, returning an error wrapping ctx.Err(), and is
+// passed to every transformer. A file that fails stops the build with an error
+// wrapping its *FileError, unless Options.KeepGoing is set.
+func Build(ctx context.Context, opts Options) (*Result, error) {
+	b := &builder{
+		opts:     opts,
+		config:   DefaultConfig(),
+		source:   opts.Source,
+		out:      opts.Output,
+		written:  make(writtenFS),
+		start:    time.Now(),
+		licenses: &licenseBundle{},
+		manifest: assetid.AssetManifest{
+			Assets:    make(map[string]string),
+			Integrity: make(map[string]string),
+		},
+	}
+	if opts.Config != nil {
+		if err := opts.Config.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid config: %w", err)
+		}
+		b.config = *opts.Config
+	}
+	b.result = &Result{Manifest: b.manifest, Dependencies: make(map[string][]string)}
+
+	if b.source == nil {
+		if opts.SourceDir == "" {
+			return nil, errors.New("a source directory or file system is required")
+		}
+		b.source = os.DirFS(opts.SourceDir)
+	}
+	if b.out == nil {
+		if opts.OutputDir == "" {
+			return nil, errors.New("an output directory or Output is required")
+		}
+		b.out = Dir(opts.OutputDir)
+	}
+
+	registry, err := b.newRegistry()
+	if err != nil {
+		return nil, err
+	}
+	b.commands, b.registry = b.newCommands(), registry
+
+	if err := b.prepareOutput(); err != nil {
+		return nil, err
+	}
+	if err := b.run(ctx); err != nil {
+		b.abort()
+		return nil, err
+	}
+	// the partial output is kept, unless it would replace a complete one
+	if len(b.failures) > 0 && b.opts.Atomic {
+		b.abort()
+		b.result.Stats.Duration = time.Since(b.start)
+		return b.result, &IncompleteError{Errors: b.failures}
+	}
+	if err := b.publish(); err != nil {
+		return nil, err
+	}
+	b.result.Stats.Duration = time.Since(b.start)
+	if len(b.failures) > 0 {
+		return b.result, &IncompleteError{Errors: b.failures}
+	}
+	return b.result, nil
+}
+
+// run writes the assets, pages and manifests of the build
+func (b *builder) run(ctx context.Context) error {
+	// HTML pages are rewritten once every asset they may reference has been fingerprinted
+	var pages []string
+	b.runCommands(ctx)
+
+	// Walk through all files in the source
+	err := fs.WalkDir(b.source, ".", func(sourcePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// a missing source directory is not the failure of a file
+			if sourcePath == "." {
+				return err
+			}
+			return b.fail(ctx, filepath.FromSlash(sourcePath), StageRead, err)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// Skip directories
+		if entry.IsDir() {
+			return nil
+		}
+
+		relPath := filepath.FromSlash(sourcePath)
+		if htmlrewrite.IsPage(relPath) {
+			pages = append(pages, relPath)
+			return nil
+		}
+
+		sourceCode, err := b.readSource(relPath)
+		if err != nil {
+			return b.fail(ctx, relPath, StageRead, err)
+		}
+
+		result, name, err := b.transformFile(ctx, relPath, sourceCode)
+		if err != nil {
+			return b.fail(ctx, relPath, StageTransform, err)
+		}
+		content := result.Data
+
+		// Calculate the hash of the content being written, so the name changes whenever the output does
+		hash, err := assetid.Hash(bytes.NewReader(content))
+		if err != nil {
+			return b.fail(ctx, relPath, StageTransform, fmt.Errorf("failed to calculate hash for %s: %w", sourcePath, err))
+		}
+
+		if err := b.writeAsset(AssetWritten, relPath, name, assetid.FingerprintedName(name, hash), content, result.Dependencies); err != nil {
+			return b.fail(ctx, relPath, StageWrite, err)
+		}
+		b.licenses.commit(result.Path)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to process assets: %w", err)
+	}
+
+	// The license file is fingerprinted like any other asset, so pages can link to it
+	if content := b.licenses.content(); content != nil && b.config.LicenseFile != "" {
+		if err := b.writeLicenseFile(b.config.LicenseFile, content); err != nil {
+			if err := b.fail(ctx, b.config.LicenseFile, StageWrite, err); err != nil {
+				return fmt.Errorf("failed to process assets: %w", err)
+			}
+		}
+	}
+
+	// HTML pages keep their names so they can be linked to, and are never cached immutably
+	rewriter := &htmlrewrite.Rewriter{Assets: b.manifest.Assets, Integrity: b.manifest.Integrity, AddIntegrity: b.opts.HTMLIntegrity}
+	for _, relPath := range pages {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("failed to process assets: %w", err)
+		}
+		if err := b.writePage(ctx, rewriter, relPath); err != nil {
+			return fmt.Errorf("failed to process assets: %w", err)
+		}
+	}
+	for _, warning := range rewriter.Warnings {
+		b.result.Warnings = append(b.result.Warnings, Warning{Path: warning.Page, Message: warning.Message})
+	}
+	b.manifest.Incomplete = len(b.failures) > 0
+	b.result.Manifest.Incomplete = b.manifest.Incomplete
+
+	// the manifest makes the build visible, so it is not written once canceled
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("failed to process assets: %w", err)
+	}
+
+	// Write manifest file
+	manifest, err := json.MarshalIndent(b.manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := b.out.WriteFile(assetid.ManifestFile, append(manifest, '\n')); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	for _, format := range b.opts.Exports {
+		if err := b.writeExport(format); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newCommands chains the configured commands, which run before every other transformer
+func (b *builder) newCommands() *transform.Registry {
+	commands := &transform.Registry{}
+	for _, command := range b.config.Commands {
+		// patterns were validated with the configuration
+		commands.Add(transform.MustGlob(command.Match), command.command(b.opts.SourceDir))
+	}
+	return commands
+}
+
+// newRegistry chains the transformers of a build that run after the
+// configured commands: custom transformers first, then license comment
+// collection, minification and the banner
+func (b *builder) newRegistry() (*transform.Registry, error) {
+	registry := &transform.Registry{}
+	for _, custom := range b.opts.Transformers {
+		registry.Add(custom.Match, custom.Transformer)
+	}
+
+	scripts := transform.MediaType("text/javascript", "text/css")
+	registry.Add(scripts, b.licenses)
+	if b.opts.Minify {
+		registry.Add(transform.All(), newMinifier(b.config.Minify))
+	}
+
+	banner, err := parseBanner(b.config.Banner)
+	if err != nil {
+		return nil, err
+	}
+	if banner != nil {
+		registry.Add(scripts, &bannerTransformer{banner: banner, version: b.opts.Version})
+	}
+	return registry, nil
+}
+
+// readSource reads a source file, counting its size
+func (b *builder) readSource(relPath string) ([]byte, error) {
+	content, err := fs.ReadFile(b.source, filepath.ToSlash(relPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source file %s: %w", relPath, err)
+	}
+	b.result.Stats.SourceBytes += int64(len(content))
+	return content, nil
+}
+
+// transformFile runs the content of a source file through the configured
+// commands, unless runCommands already did, and the other transformers,
+// returning the result with the dependencies reported by all of them, and the
+// name the file is listed under in the manifest
+func (b *builder) transformFile(ctx context.Context, relPath string, content []byte) (*transform.Result, string, error) {
+	commanded, ok := b.commanded[relPath]
+	if ok {
+		delete(b.commanded, relPath)
+	} else {
+		commanded = b.runCommandsOn(ctx, relPath, content)
+	}
+	if commanded.err != nil {
+		return nil, "", commanded.err
+	}
+
+	result, err := b.registry.Transform(ctx, transform.File{
+		Path:      commanded.result.Path,
+		MediaType: transform.MediaTypeOf(commanded.result.Path),
+		Data:      commanded.result.Data,
+		Manifest:  b.manifest.Assets,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	// a transformer changing the extension may build two sources under the same name
+	name := filepath.FromSlash(result.Path)
+	if _, ok := b.manifest.Assets[name]; ok {
+		return nil, "", fmt.Errorf("asset %s built from %s conflicts with another asset of the same name", name, relPath)
+	}
+	result.Dependencies = append(commanded.result.Dependencies, result.Dependencies...)
+	return result, name, nil
+}
+
+// writeAsset writes the content of a file to the output under outputName,
+// adds it to the manifest under name and reports it with its dependencies
+func (b *builder) writeAsset(kind EventKind, relPath, name, outputName string, content []byte, dependencies []string) error {
+	outputPath := filepath.ToSlash(outputName)
+	if err := b.out.WriteFile(outputPath, content); err != nil {
+		return err
+	}
+	b.written[outputPath] = writtenFile{name: outputPath, size: int64(len(content)), modTime: b.start}
+
+	// Add to manifest
+	b.manifest.Assets[name] = outputName
+	b.manifest.Integrity[name] = assetid.SRI(content)
+
+	if kind == PageWritten {
+		b.result.Stats.Pages++
+	} else {
+		b.result.Stats.Assets++
+	}
+	b.result.Stats.OutputBytes += int64(len(content))
+	if len(dependencies) > 0 {
+		b.result.Dependencies[filepath.ToSlash(relPath)] = dependencies
+	}
+	if b.opts.OnEvent != nil {
+		b.opts.OnEvent(Event{Kind: kind, Path: relPath, Name: name, Output: outputName, Size: int64(len(content)), Dependencies: dependencies})
+	}
+	return nil
+}
+
+// writeLicenseFile writes the collected license comments as a fingerprinted asset named name
+func (b *builder) writeLicenseFile(name string, content []byte) error {
+	relPath := filepath.FromSlash(name)
+	if _, ok := b.manifest.Assets[relPath]; ok {
+		return fmt.Errorf("license file %s conflicts with a source file of the same name", name)
+	}
+
+	hash, err := assetid.Hash(bytes.NewReader(content))
+	if err != nil {
+		return fmt.Errorf("failed to calculate hash for %s: %w", name, err)
+	}
+	return b.writeAsset(AssetWritten, relPath, relPath, assetid.FingerprintedName(relPath, hash), content, nil)
+}
+
+// writePage rewrites the asset references of an HTML page, runs it through
+// the transformers and writes it under its original name
+func (b *builder) writePage(ctx context.Context, rewriter *htmlrewrite.Rewriter, relPath string) error {
+	page, err := b.readSource(relPath)
+	if err != nil {
+		return b.fail(ctx, relPath, StageRead, err)
+	}
+
+	page, err = rewriter.Rewrite(relPath, page)
+	if err != nil {
+		return b.fail(ctx, relPath, StageTransform, err)
+	}
+
+	result, name, err := b.transformFile(ctx, relPath, page)
+	if err != nil {
+		return b.fail(ctx, relPath, StageTransform, err)
+	}
+	if err := b.writeAsset(PageWritten, relPath, name, name, result.Data, result.Dependencies); err != nil {
+		return b.fail(ctx, relPath, StageWrite, err)
+	}
+	return nil
+}
+
+// fail handles err, which happened to the file at relPath during stage: a
+// KeepGoing build records it and carries on, any other build stops. A
+// canceled build always stops.
+func (b *builder) fail(ctx context.Context, relPath string, stage Stage, err error) error {
+	if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		return err
+	}
+	fileErr := newFileError(relPath, stage, err)
+	if !b.opts.KeepGoing {
+		return fileErr
+	}
+	b.failures = append(b.failures, *fileErr)
+	return nil
+}
+
+// writeExport writes the manifest in another ecosystem's format to the output
+func (b *builder) writeExport(format assetid.ManifestFormat) error {
+	data, err := assetid.Export(b.written, b.manifest, format)
+	if err != nil {
+		return err
+	}
+	if err := b.out.WriteFile(format.File(), data); err != nil {
+		return fmt.Errorf("failed to write %s manifest: %w", format, err)
+	}
+	return nil
+}
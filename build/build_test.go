@@ -0,0 +1,676 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jm96441n/assetid/assetid"
+	"github.com/jm96441n/assetid/transform"
+)
+
+// writeSourceFiles writes files under a new temporary source directory
+func writeSourceFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	sourceDir := t.TempDir()
+	for path, content := range files {
+		fullPath := filepath.Join(sourceDir, path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", fullPath, err)
+		}
+	}
+	return sourceDir
+}
+
+func TestBuild(t *testing.T) {
+	// Create a temporary directory for testing
+	sourceDir, err := os.MkdirTemp("", "assetid-source")
+	if err != nil {
+		t.Fatalf("Failed to create temp source dir: %v", err)
+	}
+	t.Cleanup(func() {
+		os.RemoveAll(sourceDir)
+	})
+
+	outputDir, err := os.MkdirTemp("", "assetid-output")
+	if err != nil {
+		t.Fatalf("Failed to create temp output dir: %v", err)
+	}
+	t.Cleanup(func() {
+		os.RemoveAll(outputDir)
+	})
+
+	// Create some test files with both JS and CSS
+	testFiles := map[string]string{
+		"test.js":         "function test() { return 'test'; }",
+		"app.js":          "const app = {}; console.log('app loaded');",
+		"subdir/util.js":  "function util() { return 'utility'; }",
+		"styles.css":      "body { color: #333; font-size: 16px; padding: 20px; margin: 0; }",
+		"theme.css":       "header { background-color: #f0f0f0; } footer { color: gray; }",
+		"subdir/page.css": ".page { width: 960px; margin: 0 auto; }",
+	}
+
+	for path, content := range testFiles {
+		fullPath := filepath.Join(sourceDir, path)
+		dirPath := filepath.Dir(fullPath)
+
+		if err := os.MkdirAll(dirPath, 0755); err != nil {
+			t.Fatalf("Failed to create directory %s: %v", dirPath, err)
+		}
+
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", fullPath, err)
+		}
+	}
+
+	// Process the assets
+	_, err = Build(context.Background(), Options{SourceDir: sourceDir, OutputDir: outputDir, Minify: true})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	// Check that the manifest file was created
+	manifestPath := filepath.Join(outputDir, "manifest.json")
+	manifestData, err := os.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatalf("Failed to read manifest file: %v", err)
+	}
+
+	var manifest assetid.AssetManifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		t.Fatalf("Failed to unmarshal manifest: %v", err)
+	}
+
+	// Check that all test files are in the manifest
+	if len(manifest.Assets) != len(testFiles) {
+		t.Errorf("Expected %d assets in manifest, got %d", len(testFiles), len(manifest.Assets))
+	}
+
+	for origPath := range testFiles {
+		if _, ok := manifest.Assets[origPath]; !ok {
+			t.Errorf("Asset %s not found in manifest", origPath)
+		}
+	}
+
+	// Check that all fingerprinted files exist in the output directory
+	for origPath, fingerprintedName := range manifest.Assets {
+		fingerprintedPath := filepath.Join(outputDir, fingerprintedName)
+		if _, err := os.Stat(fingerprintedPath); os.IsNotExist(err) {
+			t.Errorf("Fingerprinted file %s does not exist", fingerprintedPath)
+		}
+
+		// Check that the fingerprinted name contains a hash
+		parts := strings.Split(fingerprintedName, "-")
+		if len(parts) < 2 {
+			t.Errorf("Fingerprinted name %s does not contain a hash", fingerprintedName)
+		}
+
+		// Verify the file contents
+		origContent := testFiles[origPath]
+		fingerprintedContent, err := os.ReadFile(fingerprintedPath)
+		if err != nil {
+			t.Errorf("Failed to read fingerprinted file %s: %v", fingerprintedPath, err)
+			continue
+		}
+
+		// Integrity should match the written content
+		if got, want := manifest.Integrity[origPath], assetid.SRI(fingerprintedContent); got != want {
+			t.Errorf("Integrity for %s = %s, want %s", origPath, got, want)
+		}
+
+		// JS files should be minified (smaller than original)
+		if strings.HasSuffix(origPath, ".js") {
+			if len(fingerprintedContent) >= len(origContent) {
+				t.Errorf("JavaScript file %s does not appear to be minified", fingerprintedPath)
+			}
+		}
+
+		// CSS files should NOT be minified (should match original content)
+		if strings.HasSuffix(origPath, ".css") {
+			// Content should be identical to the original
+			if string(fingerprintedContent) != origContent {
+				t.Errorf("CSS file %s was modified. Expected unmodified content.", fingerprintedPath)
+			}
+		}
+	}
+}
+
+// TestBuildFingerprints checks that assets are named from the hash of the
+// content written, and that the same source builds to the same names
+func TestBuildFingerprints(t *testing.T) {
+	source := fstest.MapFS{
+		"app.js":       {Data: []byte("// greet\nconsole.log( 'app' );")},
+		"css/site.css": {Data: []byte("body { color: #333; }")},
+	}
+
+	var previous assetid.AssetManifest
+	for i := 0; i < 2; i++ {
+		output := MapOutput{}
+		result, err := Build(context.Background(), Options{Source: source, Output: output, Minify: true})
+		if err != nil {
+			t.Fatalf("Build failed: %v", err)
+		}
+
+		for name, fingerprinted := range result.Manifest.Assets {
+			hash, err := assetid.Hash(bytes.NewReader(output[filepath.ToSlash(fingerprinted)]))
+			if err != nil {
+				t.Fatalf("Hash failed: %v", err)
+			}
+			if want := assetid.FingerprintedName(name, hash); fingerprinted != want {
+				t.Errorf("%s built as %s, want %s", name, fingerprinted, want)
+			}
+			if len(hash) != 16 {
+				t.Errorf("Expected hash length 16, got %d", len(hash))
+			}
+		}
+		if i > 0 && !reflect.DeepEqual(result.Manifest.Assets, previous.Assets) {
+			t.Errorf("Rebuild changed the manifest: %v, previously %v", result.Manifest.Assets, previous.Assets)
+		}
+		previous = result.Manifest
+	}
+}
+
+func TestBuildMinify(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		wantErr     bool
+		wantMissing string
+	}{
+		{
+			name: "app.js",
+			content: `
+				// This is a comment
+				function hello() {
+					console.log("Hello, world!");
+					return {
+						message: "Hello",
+						value: 42
+					};
+				}
+			`,
+			wantMissing: "This is a comment",
+		},
+		{
+			name:    "broken.js",
+			content: "function (",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := MapOutput{}
+			source := fstest.MapFS{tt.name: {Data: []byte(tt.content)}}
+			result, err := Build(context.Background(), Options{Source: source, Output: output, Minify: true})
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected error minifying invalid JS, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Build failed: %v", err)
+			}
+
+			minified := string(output[result.Manifest.Assets[tt.name]])
+			if len(minified) >= len(tt.content) {
+				t.Errorf("Minified content is not smaller than original")
+			}
+			if strings.Contains(minified, tt.wantMissing) {
+				t.Errorf("Comments were not removed in minified content")
+			}
+			if strings.Contains(minified, "    ") {
+				t.Errorf("Unnecessary whitespace was not removed in minified content")
+			}
+		})
+	}
+}
+
+// TestCSSNoMinification specifically tests that CSS files are not minified
+func TestCSSNoMinification(t *testing.T) {
+	// Create a temporary directory for testing
+	sourceDir, err := os.MkdirTemp("", "assetid-css-source")
+	if err != nil {
+		t.Fatalf("Failed to create temp source dir: %v", err)
+	}
+	t.Cleanup(func() {
+		os.RemoveAll(sourceDir)
+	})
+
+	outputDir, err := os.MkdirTemp("", "assetid-css-output")
+	if err != nil {
+		t.Fatalf("Failed to create temp output dir: %v", err)
+	}
+	t.Cleanup(func() {
+		os.RemoveAll(outputDir)
+	})
+
+	// Create a CSS file with formatting that would be minified if we were minifying CSS
+	cssContent := `
+/* This is a CSS comment that would be removed by minification */
+body {
+    font-family: Arial, sans-serif; /* This is an inline comment */
+    color: #333333;  /* Hex color that could be shortened to #333 */
+    margin:  20px;   /* Extra spaces that would be removed */
+    padding: 15px;
+}
+
+/* Another block comment */
+.container {
+    max-width: 1200px;
+    margin: 0 auto;
+}
+`
+	cssPath := filepath.Join(sourceDir, "styles.css")
+	if err := os.WriteFile(cssPath, []byte(cssContent), 0644); err != nil {
+		t.Fatalf("Failed to write CSS file: %v", err)
+	}
+
+	// Process the assets
+	_, err = Build(context.Background(), Options{SourceDir: sourceDir, OutputDir: outputDir, Minify: true})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	// Get the fingerprinted filename from the manifest
+	manifestPath := filepath.Join(outputDir, "manifest.json")
+	manifestData, err := os.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatalf("Failed to read manifest file: %v", err)
+	}
+
+	var manifest assetid.AssetManifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		t.Fatalf("Failed to unmarshal manifest: %v", err)
+	}
+
+	fingerprintedName, ok := manifest.Assets["styles.css"]
+	if !ok {
+		t.Fatalf("CSS file not found in manifest")
+	}
+
+	// Read the fingerprinted file
+	fingerprintedPath := filepath.Join(outputDir, fingerprintedName)
+	processedContent, err := os.ReadFile(fingerprintedPath)
+	if err != nil {
+		t.Fatalf("Failed to read fingerprinted CSS file: %v", err)
+	}
+
+	// Verify that CSS is completely unchanged
+	if string(processedContent) != cssContent {
+		t.Errorf("CSS content was modified. Expected it to be unchanged.")
+		t.Errorf("Original:\n%s", cssContent)
+		t.Errorf("Processed:\n%s", string(processedContent))
+	}
+
+	// Check specifically that comments are still present
+	if !strings.Contains(string(processedContent), "/* This is a CSS comment") {
+		t.Errorf("CSS comments were removed, but should be preserved")
+	}
+
+	// Check that whitespace is preserved
+	if !strings.Contains(string(processedContent), "    font-family") {
+		t.Errorf("CSS indentation was modified, but should be preserved")
+	}
+
+	// Check that extra spaces are preserved
+	if !strings.Contains(string(processedContent), "margin:  20px") {
+		t.Errorf("CSS extra spaces were removed, but should be preserved")
+	}
+}
+
+// TestRuntimeLoaderMatchesBuild checks that fingerprinting at startup names
+// files the same way as a build, so the two are interchangeable
+func TestRuntimeLoaderMatchesBuild(t *testing.T) {
+	sourceDir := writeSourceFiles(t, map[string]string{
+		"app.js":         "const app = {}; console.log('app loaded');",
+		"subdir/util.js": "function util() { return 'utility'; }",
+		"styles.css":     "body { color: #333; }",
+		"img/logo.svg":   "<svg xmlns=\"http://www.w3.org/2000/svg\">  <rect width=\"10\" height=\"10\"/>  </svg>",
+		"data/site.json": "{ \"name\": \"site\" }",
+		"feed.xml":       "<feed>  <title>site</title>  </feed>",
+		"index.html":     "<html>\n  <link rel=\"stylesheet\" href=\"styles.css\">\n  <script src=\"app.js\"></script>\n  <img src=\"img/logo.svg\">\n</html>",
+		"docs/page.html": "<html>\n  <script src=\"/subdir/util.js\"></script>\n  <img srcset=\"../img/logo.svg 2x\">\n</html>",
+	})
+
+	withCSS := DefaultConfig()
+	withCSS.Minify.CSS.Enabled = true
+	withOverride := DefaultConfig()
+	withOverride.Minify.Overrides = []MinifyOverride{{Match: "subdir/**", Skip: true}}
+
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{name: "default config", cfg: DefaultConfig()},
+		{name: "css enabled", cfg: withCSS},
+		{name: "override", cfg: withOverride},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputDir := filepath.Join(t.TempDir(), "dist")
+			if _, err := Build(context.Background(), Options{SourceDir: sourceDir, OutputDir: outputDir, Minify: true, Config: &tt.cfg}); err != nil {
+				t.Fatalf("Build failed: %v", err)
+			}
+			built, err := assetid.NewLoader(os.DirFS(outputDir), assetid.ManifestFile)
+			if err != nil {
+				t.Fatalf("Failed to load built manifest: %v", err)
+			}
+
+			minifier, err := tt.cfg.Minifier()
+			if err != nil {
+				t.Fatalf("Minifier failed: %v", err)
+			}
+			runtime, err := assetid.NewRuntimeLoader(os.DirFS(sourceDir), assetid.WithMinifier(minifier))
+			if err != nil {
+				t.Fatalf("NewRuntimeLoader failed: %v", err)
+			}
+
+			checked := 0
+			for name, fingerprinted := range built.All() {
+				checked++
+				if got, ok := runtime.Original(fingerprinted); !ok || got != name {
+					t.Errorf("Runtime loader resolves %s to %q, %v, want %q", fingerprinted, got, ok, name)
+				}
+				if built.Integrity(name) != runtime.Integrity(name) {
+					t.Errorf("Integrity for %s differs: built %s, runtime %s", name, built.Integrity(name), runtime.Integrity(name))
+				}
+			}
+			if checked != 8 {
+				t.Errorf("Checked %d assets, want 8", checked)
+			}
+		})
+	}
+
+	invalid := Config{Minify: MinifyConfig{Overrides: []MinifyOverride{{}}}}
+	if _, err := invalid.Minifier(); err == nil {
+		t.Error("Expected error for an invalid config, got nil")
+	}
+}
+
+func TestBuildCustomTransformers(t *testing.T) {
+	sourceDir := writeSourceFiles(t, map[string]string{
+		"app.js":     "const greeting = '__GREETING__';\nconsole.log(greeting);\n",
+		"styles.css": "body { color: red; }",
+	})
+	outputDir := filepath.Join(t.TempDir(), "dist")
+
+	replace := transform.Func(func(_ context.Context, file *transform.File) (*transform.Result, error) {
+		return &transform.Result{Data: bytes.ReplaceAll(file.Data, []byte("__GREETING__"), []byte("hello"))}, nil
+	})
+	opts := Options{
+		SourceDir:    sourceDir,
+		OutputDir:    outputDir,
+		Minify:       true,
+		Transformers: []Transformer{{Match: transform.MediaType("text/javascript"), Transformer: replace}},
+	}
+	if _, err := Build(context.Background(), opts); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	loader, err := assetid.NewLoader(os.DirFS(outputDir), assetid.ManifestFile)
+	if err != nil {
+		t.Fatalf("Failed to load manifest: %v", err)
+	}
+	app, err := loader.ReadFile("app.js")
+	if err != nil {
+		t.Fatalf("Failed to read app.js: %v", err)
+	}
+	// the minifier runs after the custom transformer
+	if want := `const greeting="hello";console.log(greeting)`; string(app) != want {
+		t.Errorf("app.js = %q, want %q", app, want)
+	}
+}
+
+func TestBuildDependencies(t *testing.T) {
+	sourceDir := writeSourceFiles(t, map[string]string{
+		"styles/app.scss":   "@import 'vars';",
+		"styles/_vars.scss": "$red: #f00;",
+		"app.js":            "console.log('app');",
+	})
+
+	// stands in for a sass transformer reporting the partials a file imports
+	imports := transform.Func(func(_ context.Context, file *transform.File) (*transform.Result, error) {
+		result := &transform.Result{Data: file.Data}
+		if file.Path == "styles/app.scss" {
+			result.Dependencies = []string{"styles/_vars.scss", "../shared/_mixins.scss"}
+		}
+		return result, nil
+	})
+
+	events := make(map[string][]string)
+	result, err := Build(context.Background(), Options{
+		SourceDir:    sourceDir,
+		OutputDir:    filepath.Join(t.TempDir(), "dist"),
+		Transformers: []Transformer{{Match: transform.MustGlob("**/*.scss"), Transformer: imports}},
+		OnEvent:      func(event Event) { events[filepath.ToSlash(event.Path)] = event.Dependencies },
+	})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	want := map[string][]string{"styles/app.scss": {"styles/_vars.scss", "../shared/_mixins.scss"}}
+	if !reflect.DeepEqual(result.Dependencies, want) {
+		t.Errorf("Result.Dependencies = %v, want %v", result.Dependencies, want)
+	}
+	if got := events["styles/app.scss"]; !reflect.DeepEqual(got, want["styles/app.scss"]) {
+		t.Errorf("Event.Dependencies = %v, want %v", got, want["styles/app.scss"])
+	}
+	if got := events["app.js"]; got != nil {
+		t.Errorf("Event.Dependencies for app.js = %v, want none", got)
+	}
+}
+
+func TestBuildCommands(t *testing.T) {
+	sourceDir := writeSourceFiles(t, map[string]string{
+		"styles/app.scss": "body { color: #ff0000; }\n",
+		"app.js":          "console.log('app');",
+	})
+	outputDir := filepath.Join(t.TempDir(), "dist")
+
+	cfg := DefaultConfig()
+	cfg.Minify.CSS.Enabled = true
+	// stands in for sass, reading the file relative to the source directory
+	cfg.Commands = []CommandConfig{{Match: "**/*.scss", Run: []string{"sh", "-c", `cat "$ASSETID_PATH"`}, Ext: ".css"}}
+	if _, err := Build(context.Background(), Options{SourceDir: sourceDir, OutputDir: outputDir, Minify: true, Config: &cfg}); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	loader, err := assetid.NewLoader(os.DirFS(outputDir), assetid.ManifestFile)
+	if err != nil {
+		t.Fatalf("Failed to load manifest: %v", err)
+	}
+	if _, ok := loader.Asset("styles/app.scss"); ok {
+		t.Error("Expected styles/app.scss to be renamed in the manifest")
+	}
+	css, err := loader.ReadFile("styles/app.css")
+	if err != nil {
+		t.Fatalf("Failed to read styles/app.css: %v", err)
+	}
+	// the output is minified as CSS after the command changes its extension
+	if want := "body{color:red}"; string(css) != want {
+		t.Errorf("styles/app.css = %q, want %q", css, want)
+	}
+	if path := loader.Path("styles/app.css"); !strings.HasPrefix(path, "/dist/styles/app-") || !strings.HasSuffix(path, ".css") {
+		t.Errorf("Expected fingerprinted styles/app.css, got %s", path)
+	}
+}
+
+func TestBuildCommandConcurrency(t *testing.T) {
+	files := make(map[string]string)
+	for i := range 6 {
+		files[fmt.Sprintf("styles/%d.scss", i)] = fmt.Sprintf("a { order: %d }", i)
+	}
+	sourceDir := writeSourceFiles(t, files)
+
+	tests := []struct {
+		name        string
+		concurrency int
+		// wantParallel is whether several files must have been transformed at once
+		wantParallel bool
+	}{
+		{name: "one at a time", concurrency: 1},
+		{name: "parallel", concurrency: 3, wantParallel: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// each run leaves a file in running while it sleeps and logs how many it sees
+			running, log := t.TempDir(), filepath.Join(t.TempDir(), "log")
+			script := `touch "$RUNNING/$$"; ls "$RUNNING" | wc -l >> "$LOG"; sleep 0.2; rm "$RUNNING/$$"; cat`
+
+			cfg := DefaultConfig()
+			cfg.Commands = []CommandConfig{{
+				Match:       "**/*.scss",
+				Run:         []string{"sh", "-c", script},
+				Env:         map[string]string{"RUNNING": running, "LOG": log},
+				Concurrency: tt.concurrency,
+			}}
+			result, err := Build(context.Background(), Options{SourceDir: sourceDir, OutputDir: filepath.Join(t.TempDir(), "dist"), Config: &cfg})
+			if err != nil {
+				t.Fatalf("Build failed: %v", err)
+			}
+			if result.Stats.Assets != len(files) {
+				t.Errorf("Stats.Assets = %d, want %d", result.Stats.Assets, len(files))
+			}
+
+			data, err := os.ReadFile(log)
+			if err != nil {
+				t.Fatalf("Failed to read log: %v", err)
+			}
+			peak := 0
+			for _, field := range strings.Fields(string(data)) {
+				n, err := strconv.Atoi(field)
+				if err != nil {
+					t.Fatalf("Unexpected log line %q", field)
+				}
+				peak = max(peak, n)
+			}
+			if peak > tt.concurrency || (peak > 1) != tt.wantParallel {
+				t.Errorf("Peak concurrency = %d, want at most %d, parallel %v", peak, tt.concurrency, tt.wantParallel)
+			}
+		})
+	}
+}
+
+func TestBuildCommandErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		command CommandConfig
+		wantErr string
+	}{
+		{
+			name:    "stderr in error",
+			files:   map[string]string{"app.scss": "body {"},
+			command: CommandConfig{Match: "*.scss", Run: []string{"sh", "-c", "echo 'expected \"}\".' >&2; exit 65"}},
+			wantErr: `failed to transform app.scss: failed to run sh: exit status 65: expected "}".`,
+		},
+		{
+			name:    "output conflicts with a source file",
+			files:   map[string]string{"app.css": "a{}", "app.scss": "a{}"},
+			command: CommandConfig{Match: "*.scss", Run: []string{"cat"}, Ext: ".css"},
+			wantErr: "asset app.css built from app.scss conflicts with another asset of the same name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Commands = []CommandConfig{tt.command}
+			opts := Options{SourceDir: writeSourceFiles(t, tt.files), OutputDir: filepath.Join(t.TempDir(), "dist"), Config: &cfg}
+
+			_, err := Build(context.Background(), opts)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Build error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuildResult(t *testing.T) {
+	sourceDir := writeSourceFiles(t, map[string]string{
+		"app.js":     "/*! app | MIT */\nconsole.log('app');\n",
+		"index.html": `<script src="app.js"></script><img src="missing.png" srcset="gone.png 2x"><link rel="canonical" href="/about">`,
+	})
+	outputDir := filepath.Join(t.TempDir(), "dist")
+
+	var events []Event
+	result, err := Build(context.Background(), Options{
+		SourceDir: sourceDir,
+		OutputDir: outputDir,
+		OnEvent:   func(event Event) { events = append(events, event) },
+	})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	manifest, err := assetid.ReadManifest(os.DirFS(outputDir), assetid.ManifestFile)
+	if err != nil {
+		t.Fatalf("Failed to read manifest: %v", err)
+	}
+	if !reflect.DeepEqual(result.Manifest, manifest) {
+		t.Errorf("Result manifest = %+v, want the written manifest %+v", result.Manifest, manifest)
+	}
+
+	var kinds []EventKind
+	var names []string
+	for _, event := range events {
+		kinds = append(kinds, event.Kind)
+		names = append(names, event.Name)
+		if event.Output != manifest.Assets[event.Name] {
+			t.Errorf("Event output for %s = %s, want %s", event.Name, event.Output, manifest.Assets[event.Name])
+		}
+	}
+	if want := []EventKind{AssetWritten, AssetWritten, PageWritten}; !slices.Equal(kinds, want) {
+		t.Errorf("Event kinds = %v, want %v", kinds, want)
+	}
+	if want := []string{"app.js", "LICENSES.txt", "index.html"}; !slices.Equal(names, want) {
+		t.Errorf("Event names = %v, want %v", names, want)
+	}
+
+	stats := result.Stats
+	if stats.Assets != 2 || stats.Pages != 1 {
+		t.Errorf("Stats = %d assets and %d pages, want 2 and 1", stats.Assets, stats.Pages)
+	}
+	var written int64
+	for _, event := range events {
+		written += event.Size
+	}
+	if stats.OutputBytes != written || stats.SourceBytes == 0 || stats.Duration <= 0 {
+		t.Errorf("Stats = %+v, want %d output bytes and a source size and duration", stats, written)
+	}
+
+	want := []Warning{
+		{Path: "index.html", Message: "references missing.png, which is not in the build"},
+		{Path: "index.html", Message: "references gone.png, which is not in the build"},
+	}
+	if !reflect.DeepEqual(result.Warnings, want) {
+		t.Errorf("Warnings = %v, want %v", result.Warnings, want)
+	}
+}
+
+func TestBuildInvalidConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Minify.Overrides = []MinifyOverride{{Match: "vendor/[a.js"}}
+
+	opts := Options{SourceDir: writeSourceFiles(t, map[string]string{"app.js": "x()"}), OutputDir: filepath.Join(t.TempDir(), "dist"), Config: &cfg}
+	if _, err := Build(context.Background(), opts); err == nil {
+		t.Error("Expected error for invalid config, got nil")
+	}
+}
@@ -0,0 +1,97 @@
+package build
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/jm96441n/assetid/internal/htmlrewrite"
+	"github.com/jm96441n/assetid/transform"
+)
+
+// commandResult is the output of the configured commands for a source file
+type commandResult struct {
+	result *transform.Result
+	err    error
+}
+
+// runCommands runs the configured commands on the assets they match before
+// the rest of the build, which handles one file at a time, so that slow
+// programs such as sass run on several files at once. As many files are in
+// flight as the most concurrent command allows, and each command limits
+// itself to its own Concurrency. Pages are rewritten before their commands
+// run, so they are left to transformFile, as are files that cannot be read.
+func (b *builder) runCommands(ctx context.Context) {
+	if len(b.config.Commands) == 0 {
+		return
+	}
+
+	// errors are reported by the build's own walk
+	var assets []string
+	_ = fs.WalkDir(b.source, ".", func(sourcePath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return nil
+		}
+		relPath := filepath.FromSlash(sourcePath)
+		if !htmlrewrite.IsPage(relPath) && b.commands.Matches(sourcePath, transform.MediaTypeOf(relPath)) {
+			assets = append(assets, relPath)
+		}
+		return nil
+	})
+
+	results := make([]*commandResult, len(assets))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(b.commandWorkers(), len(assets)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				content, err := fs.ReadFile(b.source, filepath.ToSlash(assets[i]))
+				if err != nil {
+					continue
+				}
+				result := b.runCommandsOn(ctx, assets[i], content)
+				results[i] = &result
+			}
+		}()
+	}
+	for i := range assets {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	b.commanded = make(map[string]commandResult, len(assets))
+	for i, relPath := range assets {
+		if results[i] != nil {
+			b.commanded[relPath] = *results[i]
+		}
+	}
+}
+
+// commandWorkers returns the Concurrency of the most concurrent command
+func (b *builder) commandWorkers() int {
+	workers := 1
+	for _, command := range b.config.Commands {
+		concurrency := command.Concurrency
+		if concurrency <= 0 {
+			concurrency = runtime.NumCPU()
+		}
+		workers = max(workers, concurrency)
+	}
+	return workers
+}
+
+// runCommandsOn runs the configured commands on the content of a source file
+func (b *builder) runCommandsOn(ctx context.Context, relPath string, content []byte) commandResult {
+	result, err := b.commands.Transform(ctx, transform.File{
+		Path:      filepath.ToSlash(relPath),
+		MediaType: transform.MediaTypeOf(relPath),
+		Data:      content,
+		Manifest:  b.manifest.Assets,
+	})
+	return commandResult{result: result, err: err}
+}
@@ -0,0 +1,292 @@
+package build
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jm96441n/assetid/transform"
+)
+
+// Config is the build configuration, read by the CLI from the file given with --config
+type Config struct {
+	// Minify holds the options of each minifier used when Options.Minify is set
+	Minify MinifyConfig `json:"minify"`
+	// Banner is a text/template prepended to JS and CSS files as a /*! */
+	// comment, with the file's .Name, the build's .Version and the .Hash of the
+	// file's content before the banner is added
+	Banner string `json:"banner"`
+	// LicenseFile is the asset collecting the license comments of every JS and
+	// CSS file, written if any are found; empty disables it
+	LicenseFile string `json:"licenseFile"`
+	// Commands run external programs on matching files before minification
+	Commands []CommandConfig `json:"commands"`
+}
+
+// defaultCommandTimeout stops commands configured without a timeout
+const defaultCommandTimeout = time.Minute
+
+// CommandConfig runs an external program on the files matching a glob
+type CommandConfig struct {
+	// Match is a glob matched against paths relative to the source directory
+	Match string `json:"match"`
+	// Run is the program and its arguments
+	Run []string `json:"run"`
+	// Input is "stdin", the default, or "file" to pass a temporary file in place of the {file} argument
+	Input string `json:"input"`
+	// Ext replaces the extension of the output, e.g. .css for sass
+	Ext string `json:"ext"`
+	// Env holds variables added to the program's environment
+	Env map[string]string `json:"env"`
+	// ClearEnv starts the program with only the variables in Env
+	ClearEnv bool `json:"clearEnv"`
+	// Timeout stops the program on a single file; 0 uses defaultCommandTimeout
+	Timeout Duration `json:"timeout"`
+	// Concurrency is the number of files the program runs on at once; 0 uses the number of CPUs
+	Concurrency int `json:"concurrency"`
+}
+
+// validate reports whether the command can be run
+func (c CommandConfig) validate() error {
+	if c.Match == "" {
+		return errors.New("match is required")
+	}
+	if _, err := transform.Glob(c.Match); err != nil {
+		return err
+	}
+	if len(c.Run) == 0 || c.Run[0] == "" {
+		return errors.New("run is required")
+	}
+	switch c.Input {
+	case "", "stdin":
+	case "file":
+		if !slices.ContainsFunc(c.Run, func(arg string) bool { return strings.Contains(arg, transform.FileArg) }) {
+			return fmt.Errorf("file input requires a %s argument", transform.FileArg)
+		}
+	default:
+		return fmt.Errorf("unknown input %q", c.Input)
+	}
+	if c.Ext != "" && (!strings.HasPrefix(c.Ext, ".") || strings.Contains(c.Ext, "/")) {
+		return fmt.Errorf("invalid ext %q", c.Ext)
+	}
+	if c.Timeout < 0 || c.Concurrency < 0 {
+		return errors.New("timeout and concurrency must not be negative")
+	}
+	return nil
+}
+
+// command returns the transformer running the program from dir
+func (c CommandConfig) command(dir string) *transform.Command {
+	env := make([]string, 0, len(c.Env))
+	for key, value := range c.Env {
+		env = append(env, key+"="+value)
+	}
+	slices.Sort(env)
+
+	timeout := time.Duration(c.Timeout)
+	if timeout == 0 {
+		timeout = defaultCommandTimeout
+	}
+	return &transform.Command{
+		Args:        c.Run,
+		FileInput:   c.Input == "file",
+		Ext:         c.Ext,
+		Dir:         dir,
+		Env:         env,
+		ClearEnv:    c.ClearEnv,
+		Timeout:     timeout,
+		Concurrency: c.Concurrency,
+	}
+}
+
+// Duration is a time.Duration read from a string such as "30s"
+type Duration time.Duration
+
+// UnmarshalJSON parses the duration with time.ParseDuration
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("duration must be a string such as \"30s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MinifyConfig holds the options of each minifier and the overrides for files
+// matching a glob. Omitted options keep the minifier's defaults.
+type MinifyConfig struct {
+	MinifyOptions
+	// Overrides are checked in order; the first one matching a file applies to it
+	Overrides []MinifyOverride `json:"overrides"`
+}
+
+// MinifyOptions holds the options of each minifier
+type MinifyOptions struct {
+	JS   JSMinifyOptions   `json:"js"`
+	CSS  CSSMinifyOptions  `json:"css"`
+	HTML HTMLMinifyOptions `json:"html"`
+	SVG  SVGMinifyOptions  `json:"svg"`
+	JSON JSONMinifyOptions `json:"json"`
+	XML  XMLMinifyOptions  `json:"xml"`
+}
+
+// MinifyOverride replaces the minifier options for files matching a glob
+type MinifyOverride struct {
+	// Match is a glob matched against paths relative to the source directory; ** matches any number of directories
+	Match string `json:"match"`
+	// Skip writes matching files without minifying them
+	Skip bool `json:"skip"`
+	MinifyOptions
+}
+
+// JSMinifyOptions configures the JavaScript minifier
+type JSMinifyOptions struct {
+	// KeepVarNames keeps variable and function names, for code relying on Function.prototype.name
+	KeepVarNames bool `json:"keepVarNames"`
+	// Precision is the number of significant digits kept in numbers; 0 keeps them all
+	Precision int `json:"precision"`
+	// Version is the ECMAScript version, e.g. 2015, that output may use; 0 allows the latest
+	Version int `json:"version"`
+	// KeepLicenseComments keeps /*! and @license comments anywhere in the file
+	KeepLicenseComments bool `json:"keepLicenseComments"`
+}
+
+// CSSMinifyOptions configures the CSS minifier, which only runs if enabled
+type CSSMinifyOptions struct {
+	Enabled  bool `json:"enabled"`
+	KeepCSS2 bool `json:"keepCSS2"`
+	// Precision is the number of significant digits kept in numbers; 0 keeps them all
+	Precision int `json:"precision"`
+	// KeepLicenseComments keeps /*! and @license comments anywhere in the file
+	KeepLicenseComments bool `json:"keepLicenseComments"`
+}
+
+// HTMLMinifyOptions configures the HTML minifier
+type HTMLMinifyOptions struct {
+	KeepComments            bool `json:"keepComments"`
+	KeepConditionalComments bool `json:"keepConditionalComments"`
+	KeepSpecialComments     bool `json:"keepSpecialComments"`
+	KeepDefaultAttrVals     bool `json:"keepDefaultAttrVals"`
+	KeepDocumentTags        bool `json:"keepDocumentTags"`
+	KeepEndTags             bool `json:"keepEndTags"`
+	KeepQuotes              bool `json:"keepQuotes"`
+	KeepWhitespace          bool `json:"keepWhitespace"`
+}
+
+// SVGMinifyOptions configures the SVG minifier
+type SVGMinifyOptions struct {
+	KeepComments bool `json:"keepComments"`
+	// Precision is the number of significant digits kept in numbers; 0 keeps them all
+	Precision int `json:"precision"`
+}
+
+// JSONMinifyOptions configures the JSON minifier
+type JSONMinifyOptions struct {
+	// Precision is the number of significant digits kept in numbers; 0 keeps them all
+	Precision   int  `json:"precision"`
+	KeepNumbers bool `json:"keepNumbers"`
+}
+
+// XMLMinifyOptions configures the XML minifier
+type XMLMinifyOptions struct {
+	KeepWhitespace bool `json:"keepWhitespace"`
+}
+
+// DefaultConfig returns the configuration used without a configuration file,
+// and the base every configuration file is read on top of
+func DefaultConfig() Config {
+	var cfg Config
+	cfg.Minify.JS.KeepLicenseComments = true
+	cfg.Minify.CSS.KeepLicenseComments = true
+	cfg.LicenseFile = "LICENSES.txt"
+	return cfg
+}
+
+// LoadConfig reads a configuration file on top of the defaults and validates it
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	cfg := DefaultConfig()
+	if err := decodeStrict(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Validate reports the first problem with the configuration, such as an
+// invalid glob or banner template
+func (c Config) Validate() error {
+	for i, override := range c.Minify.Overrides {
+		if override.Match == "" {
+			return fmt.Errorf("override %d: match is required", i)
+		}
+		if _, err := transform.Glob(override.Match); err != nil {
+			return fmt.Errorf("override %d: %w", i, err)
+		}
+	}
+	if _, err := parseBanner(c.Banner); err != nil {
+		return err
+	}
+	if c.LicenseFile != "" && !fs.ValidPath(c.LicenseFile) {
+		return fmt.Errorf("invalid licenseFile %q", c.LicenseFile)
+	}
+	for i, command := range c.Commands {
+		if err := command.validate(); err != nil {
+			return fmt.Errorf("command %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON reads the minifier options, then reads each override on top
+// of them so an override only needs the options that differ
+func (c *MinifyConfig) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		MinifyOptions
+		Overrides []json.RawMessage `json:"overrides"`
+	}
+	raw.MinifyOptions = c.MinifyOptions
+	if err := decodeStrict(data, &raw); err != nil {
+		return err
+	}
+
+	c.MinifyOptions = raw.MinifyOptions
+	c.Overrides = nil
+	for i, data := range raw.Overrides {
+		override := MinifyOverride{MinifyOptions: c.MinifyOptions}
+		if err := decodeStrict(data, &override); err != nil {
+			return fmt.Errorf("override %d: %w", i, err)
+		}
+		c.Overrides = append(c.Overrides, override)
+	}
+	return nil
+}
+
+// decodeStrict decodes JSON into v, rejecting unknown fields so typos do not go unnoticed
+func decodeStrict(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return errors.New("unexpected data after JSON value")
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		content string
+		// want changes the default configuration into the expected one
+		want    func(cfg *Config)
+		wantErr bool
+	}{
+		{
+			name:    "minifier options",
+			content: `{"minify": {"html": {"keepQuotes": true}, "svg": {"precision": 3}, "json": {"keepNumbers": true}, "xml": {"keepWhitespace": true}}}`,
+			want: func(cfg *Config) {
+				cfg.Minify.HTML.KeepQuotes = true
+				cfg.Minify.SVG.Precision = 3
+				cfg.Minify.JSON.KeepNumbers = true
+				cfg.Minify.XML.KeepWhitespace = true
+			},
+		},
+		{
+			name: "overrides inherit the minifier options",
+			content: `{"minify": {
+				"js": {"precision": 4},
+				"css": {"enabled": true},
+				"overrides": [
+					{"match": "vendor/legacy/*.js", "skip": true},
+					{"match": "vendor/**/*.js", "js": {"keepVarNames": true, "keepLicenseComments": false}}
+				]
+			}}`,
+			want: func(cfg *Config) {
+				cfg.Minify.JS.Precision = 4
+				cfg.Minify.CSS.Enabled = true
+
+				legacy := MinifyOverride{Match: "vendor/legacy/*.js", Skip: true, MinifyOptions: cfg.Minify.MinifyOptions}
+				vendor := MinifyOverride{Match: "vendor/**/*.js", MinifyOptions: cfg.Minify.MinifyOptions}
+				vendor.JS.KeepVarNames = true
+				vendor.JS.KeepLicenseComments = false
+				cfg.Minify.Overrides = []MinifyOverride{legacy, vendor}
+			},
+		},
+		{
+			name:    "banner and license file",
+			content: `{"banner": "{{.Name}} v{{.Version}}", "licenseFile": "legal/THIRD_PARTY.txt"}`,
+			want: func(cfg *Config) {
+				cfg.Banner = "{{.Name}} v{{.Version}}"
+				cfg.LicenseFile = "legal/THIRD_PARTY.txt"
+			},
+		},
+		{
+			name: "commands",
+			content: `{"commands": [
+				{"match": "**/*.scss", "run": ["sass", "--stdin"], "ext": ".css", "timeout": "30s", "env": {"NODE_ENV": "production"}},
+				{"match": "img/*.png", "run": ["optipng", "-quiet", "{file}", "-out", "/dev/stdout"], "input": "file", "concurrency": 2, "clearEnv": true}
+			]}`,
+			want: func(cfg *Config) {
+				cfg.Commands = []CommandConfig{
+					{Match: "**/*.scss", Run: []string{"sass", "--stdin"}, Ext: ".css", Timeout: Duration(30 * time.Second), Env: map[string]string{"NODE_ENV": "production"}},
+					{Match: "img/*.png", Run: []string{"optipng", "-quiet", "{file}", "-out", "/dev/stdout"}, Input: "file", Concurrency: 2, ClearEnv: true},
+				}
+			},
+		},
+		{
+			name:    "empty",
+			content: `{}`,
+			want:    func(cfg *Config) {},
+		},
+		{
+			name:    "unknown field",
+			content: `{"minify": {"svg": {"precission": 3}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown override field",
+			content: `{"minify": {"overrides": [{"match": "*.js", "js": {"keepNames": true}}]}}`,
+			wantErr: true,
+		},
+		{
+			name:    "override without match",
+			content: `{"minify": {"overrides": [{"skip": true}]}}`,
+			wantErr: true,
+		},
+		{
+			name:    "override with invalid glob",
+			content: `{"minify": {"overrides": [{"match": "vendor/[a.js"}]}}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid banner",
+			content: `{"banner": "{{.Version"}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid license file",
+			content: `{"licenseFile": "../LICENSES.txt"}`,
+			wantErr: true,
+		},
+		{
+			name:    "command without run",
+			content: `{"commands": [{"match": "*.scss"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "command with file input and no file argument",
+			content: `{"commands": [{"match": "*.png", "run": ["optipng"], "input": "file"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "command with unknown input",
+			content: `{"commands": [{"match": "*.scss", "run": ["sass"], "input": "pipe"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "command with invalid ext",
+			content: `{"commands": [{"match": "*.scss", "run": ["sass"], "ext": "css"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "command with invalid timeout",
+			content: `{"commands": [{"match": "*.scss", "run": ["sass"], "timeout": 30}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			content: `{"minify":`,
+			wantErr: true,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "config"+string(rune('a'+i))+".json")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+
+			got, err := LoadConfig(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			want := DefaultConfig()
+			tt.want(&want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("LoadConfig() = %+v, want %+v", got, want)
+			}
+		})
+	}
+
+	if _, err := LoadConfig(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("Expected error for missing config, got nil")
+	}
+}
@@ -0,0 +1,94 @@
+package build
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/tdewolff/parse/v2"
+)
+
+// Stage is the step of a build in which a file failed
+type Stage string
+
+const (
+	// StageRead is reading the source file
+	StageRead Stage = "read"
+	// StageTransform is running the file through commands, transformers,
+	// minification and HTML rewriting
+	StageTransform Stage = "transform"
+	// StageWrite is writing the file to the output
+	StageWrite Stage = "write"
+)
+
+// Stages lists the stages in the order files go through them
+func Stages() []Stage {
+	return []Stage{StageRead, StageTransform, StageWrite}
+}
+
+// FileError is the failure of one file in a build
+type FileError struct {
+	// Path is the file's path relative to the source directory, with forward slashes
+	Path  string `json:"path"`
+	Stage Stage  `json:"stage"`
+	// Line and Column locate syntax errors found by the minifier, starting at 1
+	Line   int `json:"line,omitempty"`
+	Column int `json:"column,omitempty"`
+	// Message describes the failure, naming the file
+	Message string `json:"message"`
+	Err     error  `json:"-"`
+}
+
+// newFileError describes err, which happened to the file at relPath during stage
+func newFileError(relPath string, stage Stage, err error) *FileError {
+	fileErr := &FileError{Path: filepath.ToSlash(relPath), Stage: stage, Message: err.Error(), Err: err}
+
+	var syntaxErr *parse.Error
+	if errors.As(err, &syntaxErr) {
+		fileErr.Line, fileErr.Column = syntaxErr.Line, syntaxErr.Column
+		// the error's own text ends with the whole offending line, which is
+		// noise once minified, so the message is the location and the cause
+		fileErr.Message = fmt.Sprintf("%s:%d:%d: %s", fileErr.Path, fileErr.Line, fileErr.Column, syntaxErr.Message)
+	}
+	return fileErr
+}
+
+// Error returns the message
+func (e *FileError) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the underlying error
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// IncompleteError is returned with the result of a KeepGoing build in which
+// some files failed
+type IncompleteError struct {
+	// Errors lists the failures in the order the files were processed
+	Errors []FileError
+}
+
+// Error returns the number of files that failed
+func (e *IncompleteError) Error() string {
+	if len(e.Errors) == 1 {
+		return "failed to process assets: 1 file failed"
+	}
+	return fmt.Sprintf("failed to process assets: %d files failed", len(e.Errors))
+}
+
+// FileErrors returns the file failures behind an error returned by Build: all
+// of them for a KeepGoing build, the first one otherwise. It returns nil if
+// the build did not fail because of a file.
+func FileErrors(err error) []FileError {
+	var incomplete *IncompleteError
+	if errors.As(err, &incomplete) {
+		return incomplete.Errors
+	}
+	var fileErr *FileError
+	if errors.As(err, &fileErr) {
+		return []FileError{*fileErr}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package build
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jm96441n/assetid/assetid"
+)
+
+// failingOutput is a MapOutput failing to write files whose name starts with prefix
+type failingOutput struct {
+	MapOutput
+	prefix string
+}
+
+func (f failingOutput) WriteFile(name string, data []byte) error {
+	if strings.HasPrefix(name, f.prefix) {
+		return &os.PathError{Op: "write", Path: name, Err: errors.New("disk full")}
+	}
+	return f.MapOutput.WriteFile(name, data)
+}
+
+// brokenSource has an asset with a syntax error, one that cannot be written and a page
+func brokenSource() fstest.MapFS {
+	return fstest.MapFS{
+		"js/app.js":    {Data: []byte("console.log('app');")},
+		"js/broken.js": {Data: []byte("console.log('broken');\nlet x = ;\n")},
+		"js/full.js":   {Data: []byte("console.log('full');")},
+		"index.html":   {Data: []byte(`<script src="js/app.js"></script>`)},
+	}
+}
+
+func TestBuildKeepGoing(t *testing.T) {
+	output := failingOutput{MapOutput: MapOutput{}, prefix: "js/full-"}
+	result, err := Build(context.Background(), Options{
+		Source:    brokenSource(),
+		Output:    output,
+		Minify:    true,
+		KeepGoing: true,
+	})
+
+	var incomplete *IncompleteError
+	if !errors.As(err, &incomplete) {
+		t.Fatalf("Build error = %v, want an *IncompleteError", err)
+	}
+	if want := "failed to process assets: 2 files failed"; err.Error() != want {
+		t.Errorf("Build error = %q, want %q", err, want)
+	}
+
+	got := FileErrors(err)
+	if len(got) != 2 {
+		t.Fatalf("FileErrors() = %+v, want 2 errors", got)
+	}
+	if got[0].Path != "js/broken.js" || got[0].Stage != StageTransform || got[0].Line != 2 || got[0].Column != 9 {
+		t.Errorf("FileErrors()[0] = %+v, want a syntax error in js/broken.js on line 2 and column 9", got[0])
+	}
+	if !strings.HasPrefix(got[0].Message, "js/broken.js:2:9: ") || strings.Contains(got[0].Message, "\n") {
+		t.Errorf("FileErrors()[0].Message = %q, want a single line starting with js/broken.js:2:9", got[0].Message)
+	}
+	if got[1].Path != "js/full.js" || got[1].Stage != StageWrite || !strings.Contains(got[1].Message, "disk full") {
+		t.Errorf("FileErrors()[1] = %+v, want a write error for js/full.js", got[1])
+	}
+
+	if result == nil || !result.Manifest.Incomplete {
+		t.Fatalf("Expected a result with an incomplete manifest, got %+v", result)
+	}
+	if _, ok := result.Manifest.Assets["index.html"]; !ok {
+		t.Error("Expected the page to be built despite the failures")
+	}
+
+	var manifest assetid.AssetManifest
+	if err := json.Unmarshal(output.MapOutput[assetid.ManifestFile], &manifest); err != nil {
+		t.Fatalf("Failed to parse the written manifest: %v", err)
+	}
+	if !manifest.Incomplete {
+		t.Error("Expected the written manifest to be marked incomplete")
+	}
+	var names []string
+	for name := range manifest.Assets {
+		names = append(names, filepath.ToSlash(name))
+	}
+	if want := []string{"index.html", "js/app.js"}; !equalFiles(names, want) {
+		t.Errorf("Manifest assets = %v, want %v", names, want)
+	}
+}
+
+func TestBuildFileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want []FileError
+	}{
+		{
+			name: "stops at the first failure",
+			opts: Options{Source: brokenSource(), Output: MapOutput{}, Minify: true},
+			want: []FileError{{Path: "js/broken.js", Stage: StageTransform, Line: 2, Column: 9}},
+		},
+		{
+			name: "keep going without failures",
+			opts: Options{Source: testSource(), Output: MapOutput{}, Minify: true, KeepGoing: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Build(context.Background(), tt.opts)
+			if (err != nil) != (len(tt.want) > 0) {
+				t.Fatalf("Build error = %v, want %d file errors", err, len(tt.want))
+			}
+			if err == nil && result.Manifest.Incomplete {
+				t.Error("Expected a complete manifest")
+			}
+
+			var got []FileError
+			for _, fileErr := range FileErrors(err) {
+				got = append(got, FileError{Path: fileErr.Path, Stage: fileErr.Stage, Line: fileErr.Line, Column: fileErr.Column})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FileErrors() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildKeepGoingAtomic(t *testing.T) {
+	parent := t.TempDir()
+	outputDir := filepath.Join(parent, "dist")
+	if err := Dir(outputDir).WriteFile(assetid.ManifestFile, []byte("{}")); err != nil {
+		t.Fatalf("Failed to write previous output: %v", err)
+	}
+
+	_, err := Build(context.Background(), Options{
+		Source:    brokenSource(),
+		OutputDir: outputDir,
+		Minify:    true,
+		KeepGoing: true,
+		Atomic:    true,
+	})
+	if len(FileErrors(err)) != 1 {
+		t.Fatalf("Build error = %v, want 1 file error", err)
+	}
+
+	if got, want := listFiles(t, outputDir), []string{assetid.ManifestFile}; !equalFiles(got, want) {
+		t.Errorf("Output files = %v, want the previous output %v", got, want)
+	}
+	if entries, _ := os.ReadDir(parent); len(entries) != 1 {
+		t.Errorf("Expected no staging directory to be left, got %v", entries)
+	}
+}
@@ -0,0 +1,45 @@
+package build
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jm96441n/assetid/assetid"
+)
+
+func TestProcessAssetsHTML(t *testing.T) {
+	sourceDir := writeSourceFiles(t, map[string]string{
+		"index.html":   `<html><head><script src="js/app.js"></script></head></html>`,
+		"js/app.js":    "console.log('app');",
+		"docs/a.html":  `<script src="/js/app.js"></script>`,
+		"css/site.css": "body{}",
+	})
+	outputDir := filepath.Join(t.TempDir(), "dist")
+
+	if _, err := Build(context.Background(), Options{SourceDir: sourceDir, OutputDir: outputDir}); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	loader, err := assetid.NewLoader(os.DirFS(outputDir), assetid.ManifestFile)
+	if err != nil {
+		t.Fatalf("Failed to load manifest: %v", err)
+	}
+	fingerprinted := loader.Path("js/app.js")
+
+	for _, page := range []string{"index.html", "docs/a.html"} {
+		if got := loader.Path(page); got != "/dist/"+page {
+			t.Errorf("Expected %s under its original name, got %s", page, got)
+		}
+		content, err := os.ReadFile(filepath.Join(outputDir, page))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", page, err)
+		}
+		if !strings.Contains(string(content), path.Base(fingerprinted)) {
+			t.Errorf("Expected %s to reference %s, got %s", page, fingerprinted, content)
+		}
+	}
+}
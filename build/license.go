@@ -0,0 +1,213 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"text/template"
+
+	"github.com/jm96441n/assetid/assetid"
+	"github.com/jm96441n/assetid/transform"
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/parse/v2/css"
+	"github.com/tdewolff/parse/v2/js"
+)
+
+// isLicenseComment reports whether a block comment carries license text:
+// it starts with /*! or contains @license or @preserve
+func isLicenseComment(comment []byte) bool {
+	return bytes.HasPrefix(comment, []byte("/*!")) ||
+		bytes.Contains(comment, []byte("@license")) ||
+		bytes.Contains(comment, []byte("@preserve"))
+}
+
+// licenseComments returns the license comments of a JS or CSS file in source order
+func licenseComments(mediatype string, content []byte) [][]byte {
+	switch mediatype {
+	case "text/javascript":
+		return jsLicenseComments(content)
+	case "text/css":
+		return cssLicenseComments(content)
+	}
+	return nil
+}
+
+// jsLicenseComments lexes JavaScript for license comments. A / starts a
+// regular expression unless it follows a value, as a parser would decide, so
+// comment-like text in regular expressions is not mistaken for a comment.
+func jsLicenseComments(content []byte) [][]byte {
+	var comments [][]byte
+	lexer := js.NewLexer(parse.NewInputBytes(bytes.Clone(content)))
+	prev := js.ErrorToken
+
+	for {
+		tt, data := lexer.Next()
+		switch {
+		case tt == js.ErrorToken:
+			return comments
+		case tt == js.CommentToken || tt == js.CommentLineTerminatorToken:
+			if bytes.HasPrefix(data, []byte("/*")) && isLicenseComment(data) {
+				comments = append(comments, bytes.Clone(data))
+			}
+			continue
+		case tt == js.WhitespaceToken || tt == js.LineTerminatorToken:
+			continue
+		case (tt == js.DivToken || tt == js.DivEqToken) && !endsValue(prev):
+			tt, _ = lexer.RegExp()
+		}
+		prev = tt
+	}
+}
+
+// endsValue reports whether a token can end an expression, so a following / is division
+func endsValue(tt js.TokenType) bool {
+	switch tt {
+	case js.CloseParenToken, js.CloseBracketToken, js.CloseBraceToken,
+		js.StringToken, js.TemplateToken, js.TemplateEndToken, js.RegExpToken,
+		js.ThisToken, js.SuperToken, js.TrueToken, js.FalseToken, js.NullToken:
+		return true
+	}
+	return js.IsNumeric(tt) || js.IsIdentifier(tt)
+}
+
+// cssLicenseComments lexes CSS for license comments
+func cssLicenseComments(content []byte) [][]byte {
+	var comments [][]byte
+	lexer := css.NewLexer(parse.NewInputBytes(bytes.Clone(content)))
+	for {
+		tt, data := lexer.Next()
+		if tt == css.ErrorToken {
+			return comments
+		}
+		if tt == css.CommentToken && isLicenseComment(data) {
+			comments = append(comments, bytes.Clone(data))
+		}
+	}
+}
+
+// prependLicenseComments adds the comments the minifier dropped to the start of minified
+func prependLicenseComments(minified []byte, comments [][]byte) []byte {
+	var header bytes.Buffer
+	for _, comment := range comments {
+		if !bytes.Contains(minified, comment) {
+			header.Write(comment)
+			header.WriteByte('\n')
+		}
+	}
+	if header.Len() == 0 {
+		return minified
+	}
+	return append(header.Bytes(), minified...)
+}
+
+// licenseBundle collects the license comments of every file for the license
+// file. Comments are held back until their file is written, so files that
+// fail in a build that keeps going are left out.
+type licenseBundle struct {
+	buf     bytes.Buffer
+	pending map[string][][]byte
+}
+
+// Transform holds back the license comments of a JS or CSS file, leaving it unchanged
+func (b *licenseBundle) Transform(_ context.Context, file *transform.File) (*transform.Result, error) {
+	if comments := licenseComments(file.MediaType, file.Data); len(comments) > 0 {
+		if b.pending == nil {
+			b.pending = make(map[string][][]byte)
+		}
+		b.pending[file.Path] = comments
+	}
+	return &transform.Result{Data: file.Data}, nil
+}
+
+// commit records the license comments held back for a file once it is written
+func (b *licenseBundle) commit(relPath string) {
+	if comments, ok := b.pending[relPath]; ok {
+		delete(b.pending, relPath)
+		b.add(relPath, comments)
+	}
+}
+
+// add records the license comments of a file, once each
+func (b *licenseBundle) add(relPath string, comments [][]byte) {
+	var seen [][]byte
+	for _, comment := range comments {
+		if slices.ContainsFunc(seen, func(c []byte) bool { return bytes.Equal(c, comment) }) {
+			continue
+		}
+		if len(seen) == 0 {
+			fmt.Fprintf(&b.buf, "%s:\n\n", relPath)
+		}
+		seen = append(seen, comment)
+		b.buf.Write(comment)
+		b.buf.WriteString("\n\n")
+	}
+}
+
+// content returns the license file, or nil if no license comments were found
+func (b *licenseBundle) content() []byte {
+	if b.buf.Len() == 0 {
+		return nil
+	}
+	return bytes.TrimSuffix(b.buf.Bytes(), []byte("\n"))
+}
+
+// bannerData is the data a banner template is executed with
+type bannerData struct {
+	// Name is the asset's original path
+	Name string
+	// Version is Options.Version, set by the CLI with --build-version
+	Version string
+	// Hash is the content hash of the asset before the banner is added
+	Hash string
+}
+
+// parseBanner parses a banner template; an empty banner returns nil
+func parseBanner(text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New("banner").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid banner: %w", err)
+	}
+	return tmpl, nil
+}
+
+// bannerTransformer adds the banner to JS and CSS files
+type bannerTransformer struct {
+	banner  *template.Template
+	version string
+}
+
+// Transform adds the banner to a file
+func (t *bannerTransformer) Transform(_ context.Context, file *transform.File) (*transform.Result, error) {
+	hash, err := assetid.Hash(bytes.NewReader(file.Data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate hash: %w", err)
+	}
+
+	data, err := addBanner(t.banner, bannerData{Name: file.Path, Version: t.version, Hash: hash}, file.Data)
+	if err != nil {
+		return nil, err
+	}
+	return &transform.Result{Data: data}, nil
+}
+
+// addBanner prepends the rendered banner to content as a /*! */ comment
+func addBanner(banner *template.Template, data bannerData, content []byte) ([]byte, error) {
+	var text bytes.Buffer
+	if err := banner.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("failed to render banner: %w", err)
+	}
+	if bytes.Contains(text.Bytes(), []byte("*/")) {
+		return nil, errors.New("failed to render banner: banner must not contain */")
+	}
+
+	out := make([]byte, 0, len("/*!  */\n")+text.Len()+len(content))
+	out = append(out, "/*! "...)
+	out = append(out, text.Bytes()...)
+	out = append(out, " */\n"...)
+	return append(out, content...), nil
+}
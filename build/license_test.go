@@ -0,0 +1,183 @@
+package build
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jm96441n/assetid/assetid"
+)
+
+func TestLicenseComments(t *testing.T) {
+	tests := []struct {
+		name      string
+		mediatype string
+		content   string
+		want      []string
+	}{
+		{
+			name:      "javascript",
+			mediatype: "text/javascript",
+			content:   "/*! lib v1 | MIT */\n// @license line comments are not kept\n/* plain */\nvar a = 1; /** @license Apache-2.0 */ /* @preserve keep */",
+			want:      []string{"/*! lib v1 | MIT */", "/** @license Apache-2.0 */", "/* @preserve keep */"},
+		},
+		{
+			name:      "comment-like text in strings and regular expressions",
+			mediatype: "text/javascript",
+			content:   "var s = '/*! not a comment */'; var r = /\\/*! not a comment */g; var d = a / b /*! real */;",
+			want:      []string{"/*! real */"},
+		},
+		{
+			name:      "css",
+			mediatype: "text/css",
+			content:   "/*! normalize.css */\nbody { content: '/*! not a comment */' }\n/* @license MIT */",
+			want:      []string{"/*! normalize.css */", "/* @license MIT */"},
+		},
+		{
+			name:      "other types",
+			mediatype: "text/html",
+			content:   "<!-- @license MIT -->",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, comment := range licenseComments(tt.mediatype, []byte(tt.content)) {
+				got = append(got, string(comment))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("licenseComments() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLicenseBundle(t *testing.T) {
+	var bundle licenseBundle
+	if bundle.content() != nil {
+		t.Fatal("Expected empty bundle to have no content")
+	}
+
+	bundle.add("app.js", nil)
+	bundle.add("vendor/a.js", [][]byte{[]byte("/*! a | MIT */"), []byte("/*! a | MIT */")})
+	bundle.add("vendor/b.css", [][]byte{[]byte("/* @license ISC */")})
+
+	want := "vendor/a.js:\n\n/*! a | MIT */\n\nvendor/b.css:\n\n/* @license ISC */\n"
+	if got := string(bundle.content()); got != want {
+		t.Errorf("content() = %q, want %q", got, want)
+	}
+}
+
+func TestAddBanner(t *testing.T) {
+	banner, err := parseBanner("{{.Name}} {{.Version}} ({{.Hash}})")
+	if err != nil {
+		t.Fatalf("parseBanner failed: %v", err)
+	}
+
+	got, err := addBanner(banner, bannerData{Name: "app.js", Version: "1.2.0", Hash: "0123456789abcdef"}, []byte("x()"))
+	if err != nil {
+		t.Fatalf("addBanner failed: %v", err)
+	}
+	if want := "/*! app.js 1.2.0 (0123456789abcdef) */\nx()"; string(got) != want {
+		t.Errorf("addBanner() = %q, want %q", got, want)
+	}
+
+	closing, _ := parseBanner("{{.Name}} */ alert(1) /*")
+	if _, err := addBanner(closing, bannerData{Name: "app.js"}, nil); err == nil {
+		t.Error("Expected error for banner closing the comment, got nil")
+	}
+
+	if banner, err := parseBanner(""); banner != nil || err != nil {
+		t.Errorf("parseBanner(\"\") = %v, %v, want nil, nil", banner, err)
+	}
+}
+
+func TestBuildLicenses(t *testing.T) {
+	sourceDir := writeSourceFiles(t, map[string]string{
+		"app.js":        "/* @license MIT */\nfunction add(a, b) {\n  return a + b;\n}\n",
+		"vendor/lib.js": "/*! lib v2 | BSD */\nvar x = 1;\n",
+		"styles.css":    "/*! theme | MIT */\nbody { color: red; }\n",
+		"data.json":     `{"a": 1}`,
+	})
+	outputDir := filepath.Join(t.TempDir(), "dist")
+
+	cfg := DefaultConfig()
+	cfg.Banner = "{{.Name}} {{.Version}}"
+	opts := Options{SourceDir: sourceDir, OutputDir: outputDir, Minify: true, Config: &cfg, Version: "1.2.0"}
+	if _, err := Build(context.Background(), opts); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	loader, err := assetid.NewLoader(os.DirFS(outputDir), assetid.ManifestFile)
+	if err != nil {
+		t.Fatalf("Failed to load manifest: %v", err)
+	}
+
+	app, err := loader.ReadFile("app.js")
+	if err != nil {
+		t.Fatalf("Failed to read app.js: %v", err)
+	}
+	if want := "/*! app.js 1.2.0 */\n/* @license MIT */\nfunction add(e,t){return e+t}"; string(app) != want {
+		t.Errorf("app.js = %q, want %q", app, want)
+	}
+
+	data, err := loader.ReadFile("data.json")
+	if err != nil || strings.HasPrefix(string(data), "/*!") {
+		t.Errorf("Expected data.json without banner, got %q, %v", data, err)
+	}
+
+	licenses, err := loader.ReadFile("LICENSES.txt")
+	if err != nil {
+		t.Fatalf("Expected LICENSES.txt in the manifest: %v", err)
+	}
+	want := "app.js:\n\n/* @license MIT */\n\nstyles.css:\n\n/*! theme | MIT */\n\nvendor/lib.js:\n\n/*! lib v2 | BSD */\n"
+	if string(licenses) != want {
+		t.Errorf("LICENSES.txt = %q, want %q", licenses, want)
+	}
+	if path := loader.Path("LICENSES.txt"); !strings.HasPrefix(path, "/dist/LICENSES-") {
+		t.Errorf("Expected fingerprinted LICENSES.txt, got %s", path)
+	}
+}
+
+func TestBuildLicensesKeepGoing(t *testing.T) {
+	output := failingOutput{MapOutput: MapOutput{}, prefix: "full-"}
+	_, err := Build(context.Background(), Options{
+		Source: fstest.MapFS{
+			"app.js":    {Data: []byte("/*! app | MIT */\nx();\n")},
+			"broken.js": {Data: []byte("/*! broken | MIT */\nlet x = ;\n")},
+			"full.js":   {Data: []byte("/*! full | MIT */\ny();\n")},
+		},
+		Output:    output,
+		Minify:    true,
+		KeepGoing: true,
+	})
+	if got := len(FileErrors(err)); got != 2 {
+		t.Fatalf("Build error = %v, want 2 failed files", err)
+	}
+
+	var licenses []byte
+	for name, data := range output.MapOutput {
+		if strings.HasPrefix(name, "LICENSES-") {
+			licenses = data
+		}
+	}
+	if want := "app.js:\n\n/*! app | MIT */\n"; string(licenses) != want {
+		t.Errorf("LICENSES.txt = %q, want only the licenses of written files %q", licenses, want)
+	}
+}
+
+func TestBuildLicenseFileConflict(t *testing.T) {
+	sourceDir := writeSourceFiles(t, map[string]string{
+		"LICENSES.txt": "ours",
+		"app.js":       "/*! lib */ x()",
+	})
+
+	if _, err := Build(context.Background(), Options{SourceDir: sourceDir, OutputDir: filepath.Join(t.TempDir(), "dist")}); err == nil {
+		t.Error("Expected error for license file conflicting with a source file, got nil")
+	}
+}
@@ -0,0 +1,157 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"github.com/jm96441n/assetid/assetid"
+	"github.com/jm96441n/assetid/transform"
+
+	"github.com/tdewolff/minify/v2"
+	"github.com/tdewolff/minify/v2/css"
+	"github.com/tdewolff/minify/v2/html"
+	"github.com/tdewolff/minify/v2/js"
+	"github.com/tdewolff/minify/v2/json"
+	"github.com/tdewolff/minify/v2/svg"
+	"github.com/tdewolff/minify/v2/xml"
+)
+
+// minifier minifies assets with the minifier registered for their media type,
+// using the options of the first override matching the file if any
+type minifier struct {
+	base      *minifyProfile
+	overrides []minifyOverrideProfile
+}
+
+// minifyProfile is a set of minifiers sharing the same options
+type minifyProfile struct {
+	m *minify.M
+	// licenses lists the media types whose license comments are kept
+	licenses map[string]bool
+}
+
+// minifyOverrideProfile is the profile of an override; profile is nil for skipped files
+type minifyOverrideProfile struct {
+	match   transform.Matcher
+	profile *minifyProfile
+}
+
+// newMinifier creates a minifier from the minifier options and overrides
+func newMinifier(cfg MinifyConfig) *minifier {
+	result := &minifier{base: newMinifyProfile(cfg.MinifyOptions)}
+	for _, override := range cfg.Overrides {
+		var profile *minifyProfile
+		if !override.Skip {
+			profile = newMinifyProfile(override.MinifyOptions)
+		}
+		// patterns were validated when the configuration was loaded
+		match := transform.MustGlob(override.Match)
+		result.overrides = append(result.overrides, minifyOverrideProfile{match: match, profile: profile})
+	}
+	return result
+}
+
+// newMinifyProfile registers the JS, HTML, SVG, JSON and XML minifiers, and
+// the CSS minifier if enabled, with the given options
+func newMinifyProfile(opts MinifyOptions) *minifyProfile {
+	m := minify.New()
+	m.Add("text/javascript", &js.Minifier{
+		KeepVarNames: opts.JS.KeepVarNames,
+		Precision:    opts.JS.Precision,
+		Version:      opts.JS.Version,
+	})
+	if opts.CSS.Enabled {
+		m.Add("text/css", &css.Minifier{
+			KeepCSS2:  opts.CSS.KeepCSS2,
+			Precision: opts.CSS.Precision,
+		})
+	}
+	m.Add("text/html", &html.Minifier{
+		KeepComments:            opts.HTML.KeepComments,
+		KeepConditionalComments: opts.HTML.KeepConditionalComments,
+		KeepSpecialComments:     opts.HTML.KeepSpecialComments,
+		KeepDefaultAttrVals:     opts.HTML.KeepDefaultAttrVals,
+		KeepDocumentTags:        opts.HTML.KeepDocumentTags,
+		KeepEndTags:             opts.HTML.KeepEndTags,
+		KeepQuotes:              opts.HTML.KeepQuotes,
+		KeepWhitespace:          opts.HTML.KeepWhitespace,
+	})
+	m.Add("image/svg+xml", &svg.Minifier{
+		KeepComments: opts.SVG.KeepComments,
+		Precision:    opts.SVG.Precision,
+	})
+	m.AddRegexp(regexp.MustCompile(`[/+]json$`), &json.Minifier{
+		Precision:   opts.JSON.Precision,
+		KeepNumbers: opts.JSON.KeepNumbers,
+	})
+	m.AddRegexp(regexp.MustCompile(`[/+]xml$`), &xml.Minifier{
+		KeepWhitespace: opts.XML.KeepWhitespace,
+	})
+
+	return &minifyProfile{
+		m: m,
+		licenses: map[string]bool{
+			"text/javascript": opts.JS.KeepLicenseComments,
+			"text/css":        opts.CSS.KeepLicenseComments,
+		},
+	}
+}
+
+// Minifier returns the minifier a build with this configuration runs when
+// Options.Minify is set, for assetid.WithMinifier, so runtime loaders minify
+// every file as the build does and give it the same name. Commands, custom
+// transformers and banners are not applied.
+func (c Config) Minifier() (assetid.Minifier, error) {
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return newMinifier(c.Minify).minify, nil
+}
+
+// profile returns the profile for a file, or nil if it is not minified
+func (m *minifier) profile(slashPath, mediatype string) *minifyProfile {
+	for _, override := range m.overrides {
+		if override.match.Match(slashPath, mediatype) {
+			return override.profile
+		}
+	}
+	return m.base
+}
+
+// Transform minifies a file as a step of the build's transform pipeline
+func (m *minifier) Transform(_ context.Context, file *transform.File) (*transform.Result, error) {
+	data, err := m.minifyType(file.Path, file.MediaType, file.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to minify source: %w", err)
+	}
+	return &transform.Result{Data: data}, nil
+}
+
+// minify returns content minified by the minifier for the file's media type,
+// or content unchanged if there is none or the file is skipped
+func (m *minifier) minify(relPath string, content []byte) ([]byte, error) {
+	return m.minifyType(filepath.ToSlash(relPath), transform.MediaTypeOf(relPath), content)
+}
+
+// minifyType minifies content of the given media type
+func (m *minifier) minifyType(slashPath, mediatype string, content []byte) ([]byte, error) {
+	profile := m.profile(slashPath, mediatype)
+	if profile == nil || mediatype == "" {
+		return content, nil
+	}
+	if _, _, fn := profile.m.Match(mediatype); fn == nil {
+		return content, nil
+	}
+
+	minified, err := profile.m.Bytes(mediatype, content)
+	if err != nil {
+		return nil, err
+	}
+
+	if profile.licenses[mediatype] {
+		minified = prependLicenseComments(minified, licenseComments(mediatype, content))
+	}
+	return minified, nil
+}
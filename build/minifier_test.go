@@ -0,0 +1,160 @@
+package build
+
+import (
+	"testing"
+)
+
+func TestMinifier(t *testing.T) {
+	tests := []struct {
+		name    string
+		relPath string
+		cfg     MinifyConfig
+		content string
+		want    string
+	}{
+		{
+			name:    "javascript",
+			relPath: "app.js",
+			content: "function add(a, b) {\n  return a + b;\n}\n",
+			want:    "function add(e,t){return e+t}",
+		},
+		{
+			name:    "html",
+			relPath: "partials/nav.html",
+			content: "<nav class=\"main\">\n  <!-- links -->\n  <a href=\"/\">Home</a>\n</nav>\n",
+			want:    `<nav class=main><a href=/>Home</a></nav>`,
+		},
+		{
+			name:    "html keeping comments and quotes",
+			relPath: "partials/nav.html",
+			cfg:     MinifyConfig{MinifyOptions: MinifyOptions{HTML: HTMLMinifyOptions{KeepComments: true, KeepQuotes: true}}},
+			content: "<nav class=\"main\">\n  <!-- links -->\n  <a href=\"/\">Home</a>\n</nav>\n",
+			want:    `<nav class="main"><!-- links --><a href="/">Home</a></nav>`,
+		},
+		{
+			name:    "svg",
+			relPath: "icons/dot.svg",
+			content: "<svg xmlns=\"http://www.w3.org/2000/svg\">\n  <!-- dot -->\n  <circle cx=\"10.123456\" cy=\"10.5\" r=\"5\"/>\n</svg>\n",
+			want:    `<svg xmlns="http://www.w3.org/2000/svg"><circle cx="10.123456" cy="10.5" r="5"/></svg>`,
+		},
+		{
+			name:    "svg with precision",
+			relPath: "icons/dot.svg",
+			cfg:     MinifyConfig{MinifyOptions: MinifyOptions{SVG: SVGMinifyOptions{Precision: 3}}},
+			content: "<svg xmlns=\"http://www.w3.org/2000/svg\"><circle cx=\"10.123456\" cy=\"10.5\" r=\"5\"/></svg>",
+			want:    `<svg xmlns="http://www.w3.org/2000/svg"><circle cx="10.1" cy="10.5" r="5"/></svg>`,
+		},
+		{
+			name:    "json",
+			relPath: "data/prices.json",
+			content: "{\n  \"price\": 1.50,\n  \"tags\": [ \"a\", \"b\" ]\n}\n",
+			want:    `{"price":1.5,"tags":["a","b"]}`,
+		},
+		{
+			name:    "json keeping numbers",
+			relPath: "data/prices.json",
+			cfg:     MinifyConfig{MinifyOptions: MinifyOptions{JSON: JSONMinifyOptions{KeepNumbers: true}}},
+			content: "{\n  \"price\": 1.50\n}\n",
+			want:    `{"price":1.50}`,
+		},
+		{
+			name:    "web app manifest is json",
+			relPath: "site.webmanifest",
+			content: "{\n  \"name\": \"App\"\n}\n",
+			want:    `{"name":"App"}`,
+		},
+		{
+			name:    "xml",
+			relPath: "feed.xml",
+			content: "<feed>\n  <title>News</title>\n</feed>\n",
+			want:    `<feed><title>News</title></feed>`,
+		},
+		{
+			name:    "css is not minified",
+			relPath: "styles.css",
+			content: "body {\n  color: red;\n}\n",
+			want:    "body {\n  color: red;\n}\n",
+		},
+		{
+			name:    "javascript keeping variable names",
+			relPath: "app.js",
+			cfg:     MinifyConfig{MinifyOptions: MinifyOptions{JS: JSMinifyOptions{KeepVarNames: true}}},
+			content: "function add(first, second) {\n  return first + second;\n}\n",
+			want:    "function add(first,second){return first+second}",
+		},
+		{
+			name:    "javascript keeping license comments",
+			relPath: "app.js",
+			cfg:     MinifyConfig{MinifyOptions: MinifyOptions{JS: JSMinifyOptions{KeepLicenseComments: true}}},
+			content: "/*! app v1 */\nfunction f() {\n  /** @license MIT */\n  return 1;\n}\n",
+			want:    "/** @license MIT */\n/*! app v1 */function f(){return 1}",
+		},
+		{
+			name:    "css when enabled",
+			relPath: "styles.css",
+			cfg:     MinifyConfig{MinifyOptions: MinifyOptions{CSS: CSSMinifyOptions{Enabled: true}}},
+			content: "/* @license MIT */\nbody {\n  color: #ff0000;\n}\n",
+			want:    "body{color:red}",
+		},
+		{
+			name:    "css keeping license comments",
+			relPath: "styles.css",
+			cfg:     MinifyConfig{MinifyOptions: MinifyOptions{CSS: CSSMinifyOptions{Enabled: true, KeepLicenseComments: true}}},
+			content: "/* @license MIT */\nbody {\n  color: #ff0000;\n}\n",
+			want:    "/* @license MIT */\nbody{color:red}",
+		},
+		{
+			name:    "override skips matching files",
+			relPath: "vendor/legacy/lib.js",
+			cfg: MinifyConfig{Overrides: []MinifyOverride{
+				{Match: "vendor/legacy/*.js", Skip: true},
+			}},
+			content: "function add(a, b) {\n  return a + b;\n}\n",
+			want:    "function add(a, b) {\n  return a + b;\n}\n",
+		},
+		{
+			name:    "first matching override applies",
+			relPath: "vendor/lib/util.js",
+			cfg: MinifyConfig{Overrides: []MinifyOverride{
+				{Match: "vendor/legacy/*.js", Skip: true},
+				{Match: "vendor/**/*.js", MinifyOptions: MinifyOptions{JS: JSMinifyOptions{KeepVarNames: true}}},
+				{Match: "**/*.js", Skip: true},
+			}},
+			content: "function add(first, second) {\n  return first + second;\n}\n",
+			want:    "function add(first,second){return first+second}",
+		},
+		{
+			name:    "files not matching an override use the base options",
+			relPath: "app.js",
+			cfg: MinifyConfig{Overrides: []MinifyOverride{
+				{Match: "vendor/**", Skip: true},
+			}},
+			content: "function add(a, b) {\n  return a + b;\n}\n",
+			want:    "function add(e,t){return e+t}",
+		},
+		{
+			name:    "unknown type is untouched",
+			relPath: "LICENSE",
+			content: "MIT  License\n",
+			want:    "MIT  License\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newMinifier(tt.cfg).minify(tt.relPath, []byte(tt.content))
+			if err != nil {
+				t.Fatalf("minify failed: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("minify() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinifierErrors(t *testing.T) {
+	if _, err := newMinifier(MinifyConfig{}).minify("data.json", []byte(`{"a" 1}`)); err == nil {
+		t.Error("Expected error for invalid JSON, got nil")
+	}
+}
@@ -0,0 +1,187 @@
+package build
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Output receives the files written by a build
+type Output interface {
+	// WriteFile writes a file at name, a slash-separated path relative to the
+	// root of the output. Each name is written at most once per build.
+	WriteFile(name string, data []byte) error
+}
+
+// DirOutput writes the files of a build to a directory. It is the only
+// output that is cleaned before a build and supports Retention.
+type DirOutput struct {
+	dir string
+	// created lists the files written by the current build that did not exist before it
+	created []string
+	// deferred is set while a build writes over the files of previous builds:
+	// files that already exist, such as pages and manifests, are written to
+	// temporary files next to them and only replace them on commit
+	deferred bool
+	// replacements maps files of previous builds to the temporary files replacing them
+	replacements map[string]string
+}
+
+// Dir returns an output writing to dir, which is created if needed
+func Dir(dir string) *DirOutput {
+	return &DirOutput{dir: dir}
+}
+
+// Path returns the directory the output writes to
+func (d *DirOutput) Path() string {
+	return d.dir
+}
+
+// WriteFile writes a file under the directory, creating its parent directories
+func (d *DirOutput) WriteFile(name string, data []byte) error {
+	outputPath := filepath.Join(d.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(outputPath), err)
+	}
+	if _, err := os.Lstat(outputPath); errors.Is(err, fs.ErrNotExist) {
+		d.created = append(d.created, outputPath)
+	} else if d.deferred {
+		return d.writeReplacement(outputPath, data)
+	}
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", outputPath, err)
+	}
+	return nil
+}
+
+// writeReplacement writes the new content of an existing file to a temporary
+// file in the same directory, so commit can rename it over the file
+func (d *DirOutput) writeReplacement(outputPath string, data []byte) error {
+	temp, err := os.CreateTemp(filepath.Dir(outputPath), "."+filepath.Base(outputPath)+"-")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", outputPath, err)
+	}
+	if d.replacements == nil {
+		d.replacements = make(map[string]string)
+	}
+	d.replacements[outputPath] = temp.Name()
+
+	_, err = temp.Write(data)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(temp.Name(), 0644)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", outputPath, err)
+	}
+	return nil
+}
+
+// begin starts a build, deferring the replacement of existing files if set
+func (d *DirOutput) begin(deferred bool) {
+	d.created, d.replacements, d.deferred = nil, nil, deferred
+}
+
+// commit replaces the files of previous builds with the content the current
+// build wrote for them. If a rename fails, the replacements left are removed.
+func (d *DirOutput) commit() error {
+	paths := make([]string, 0, len(d.replacements))
+	for path := range d.replacements {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	for _, path := range paths {
+		if err := os.Rename(d.replacements[path], path); err != nil {
+			for _, temp := range d.replacements {
+				_ = os.Remove(temp)
+			}
+			d.replacements = nil
+			return fmt.Errorf("failed to replace %s: %w", path, err)
+		}
+		delete(d.replacements, path)
+	}
+	return nil
+}
+
+// discard restores the output directory to its state before the current
+// build: it removes the replacements of existing files and the files created
+// by the build, then the directories left empty, deepest first, including the
+// output directory
+func (d *DirOutput) discard() {
+	for _, temp := range d.replacements {
+		_ = os.Remove(temp)
+	}
+	d.replacements = nil
+
+	dirs := []string{d.dir}
+	for _, file := range d.created {
+		_ = os.Remove(file)
+		for dir := filepath.Dir(file); dir != d.dir && strings.HasPrefix(dir, d.dir); dir = filepath.Dir(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	d.created = nil
+
+	slices.SortFunc(dirs, func(a, b string) int {
+		if depth := strings.Count(b, string(filepath.Separator)) - strings.Count(a, string(filepath.Separator)); depth != 0 {
+			return depth
+		}
+		return strings.Compare(a, b)
+	})
+	for _, dir := range slices.Compact(dirs) {
+		// non-empty directories fail and are kept
+		_ = os.Remove(dir)
+	}
+}
+
+// MapOutput keeps the files of a build in memory, keyed by name, for
+// hermetic builds in tests
+type MapOutput map[string][]byte
+
+// WriteFile stores a copy of data under name
+func (m MapOutput) WriteFile(name string, data []byte) error {
+	m[name] = bytes.Clone(data)
+	return nil
+}
+
+// writtenFS describes the files written by a build to assetid.Export, which
+// only needs their sizes and times, whatever the output keeps of them
+type writtenFS map[string]writtenFile
+
+// Open is not supported; the content of written files is not kept
+func (w writtenFS) Open(name string) (fs.File, error) {
+	return nil, &fs.PathError{Op: "open", Path: name, Err: errors.ErrUnsupported}
+}
+
+// Stat returns the size and time of a written file
+func (w writtenFS) Stat(name string) (fs.FileInfo, error) {
+	file, ok := w[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return file, nil
+}
+
+// writtenFile is the fs.FileInfo of a written file
+type writtenFile struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (f writtenFile) Name() string       { return path.Base(f.name) }
+func (f writtenFile) Size() int64        { return f.size }
+func (f writtenFile) Mode() fs.FileMode  { return 0644 }
+func (f writtenFile) ModTime() time.Time { return f.modTime }
+func (f writtenFile) IsDir() bool        { return false }
+func (f writtenFile) Sys() any           { return nil }
@@ -0,0 +1,242 @@
+package build
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jm96441n/assetid/assetid"
+)
+
+// testSource is a source file system with assets, a duplicate asset and a page
+func testSource() fstest.MapFS {
+	return fstest.MapFS{
+		"js/app.js":    {Data: []byte("console.log('app');")},
+		"js/copy.js":   {Data: []byte("console.log('app');")},
+		"styles/a.css": {Data: []byte("body { color: red; }")},
+		"index.html":   {Data: []byte(`<script src="js/app.js"></script>`)},
+	}
+}
+
+func TestBuildFromFS(t *testing.T) {
+	output := MapOutput{}
+	result, err := Build(context.Background(), Options{
+		Source:  testSource(),
+		Output:  output,
+		Exports: []assetid.ManifestFormat{assetid.FormatSprockets},
+	})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	var manifest assetid.AssetManifest
+	if err := json.Unmarshal(output[assetid.ManifestFile], &manifest); err != nil {
+		t.Fatalf("Failed to decode manifest: %v", err)
+	}
+	if !reflect.DeepEqual(manifest, result.Manifest) {
+		t.Errorf("Written manifest = %+v, want %+v", manifest, result.Manifest)
+	}
+
+	for name, fingerprinted := range manifest.Assets {
+		if _, ok := output[filepath.ToSlash(fingerprinted)]; !ok {
+			t.Errorf("Asset %s was not written as %s", name, fingerprinted)
+		}
+	}
+	if page := string(output["index.html"]); !strings.Contains(page, manifest.Assets["js/app.js"]) {
+		t.Errorf("index.html = %q, want it to reference %s", page, manifest.Assets["js/app.js"])
+	}
+
+	var sprockets struct {
+		Files map[string]struct {
+			Size int64 `json:"size"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal(output[assetid.FormatSprockets.File()], &sprockets); err != nil {
+		t.Fatalf("Failed to decode sprockets manifest: %v", err)
+	}
+	if file := sprockets.Files[manifest.Assets["js/app.js"]]; file.Size != int64(len("console.log('app');")) {
+		t.Errorf("Sprockets size = %d, want the written size", file.Size)
+	}
+}
+
+func TestBuildOutputErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "no source", opts: Options{Output: MapOutput{}}},
+		{name: "no output", opts: Options{Source: testSource()}},
+		{name: "retention without directory", opts: Options{Source: testSource(), Output: MapOutput{}, Retention: Retention{Builds: 2}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Build(context.Background(), tt.opts); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestDirOutput(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "dist")
+	output := Dir(dir)
+
+	writes := []struct {
+		name    string
+		content string
+	}{
+		{name: "js/nested/app.js", content: "minified content"},
+		// a shorter rewrite must not leave the end of the previous content
+		{name: "js/nested/app.js", content: "short"},
+	}
+	for _, write := range writes {
+		if err := output.WriteFile(write.name, []byte(write.content)); err != nil {
+			t.Fatalf("WriteFile(%q) failed: %v", write.name, err)
+		}
+
+		path := filepath.Join(dir, filepath.FromSlash(write.name))
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read output file: %v", err)
+		}
+		if string(content) != write.content {
+			t.Errorf("File content = %q, want %q", content, write.content)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Failed to stat output file: %v", err)
+		}
+		if info.Mode().Perm() != 0644 {
+			t.Errorf("Expected file permissions 0644, got %v", info.Mode().Perm())
+		}
+	}
+
+	// a file in the way of a directory cannot be written through
+	if err := output.WriteFile("js/nested/app.js/inner.js", []byte("x")); err == nil {
+		t.Error("Expected error writing below a file, got nil")
+	}
+}
+
+// buildArchive builds the test source into an archive output and returns the
+// names of the files written, in order
+func buildArchive(t *testing.T, output Output) []string {
+	t.Helper()
+
+	var names []string
+	_, err := Build(context.Background(), Options{
+		Source:  testSource(),
+		Output:  output,
+		OnEvent: func(event Event) { names = append(names, filepath.ToSlash(event.Output)) },
+	})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	return append(names, assetid.ManifestFile)
+}
+
+func TestTarOutput(t *testing.T) {
+	var buf bytes.Buffer
+	output := Tar(&buf)
+	want := buildArchive(t, output)
+	if err := output.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	var got []string
+	tr := tar.NewReader(&buf)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read archive: %v", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil || int64(len(data)) != header.Size {
+			t.Errorf("Failed to read %s: %v", header.Name, err)
+		}
+		if !header.ModTime.Equal(archiveTime) {
+			t.Errorf("%s modified at %v, want %v", header.Name, header.ModTime, archiveTime)
+		}
+		got = append(got, header.Name)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Archive entries = %v, want %v", got, want)
+	}
+}
+
+func TestZipOutput(t *testing.T) {
+	var buf bytes.Buffer
+	output := Zip(&buf)
+	want := buildArchive(t, output)
+	if err := output.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Failed to read archive: %v", err)
+	}
+	var got []string
+	for _, file := range zr.File {
+		got = append(got, file.Name)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Archive entries = %v, want %v", got, want)
+	}
+
+	manifest, err := assetid.ReadManifest(zr, assetid.ManifestFile)
+	if err != nil || len(manifest.Assets) == 0 {
+		t.Errorf("Failed to read manifest from archive: %v", err)
+	}
+}
+
+func TestContentAddressedOutput(t *testing.T) {
+	objects := MapOutput{}
+	output := ContentAddressed(objects)
+	_, err := Build(context.Background(), Options{Source: testSource(), Output: output})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if err := output.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	var index map[string]string
+	if err := json.Unmarshal(objects[ContentAddressedIndex], &index); err != nil {
+		t.Fatalf("Failed to decode index: %v", err)
+	}
+	if !reflect.DeepEqual(index, output.Index) {
+		t.Errorf("Written index = %v, want %v", index, output.Index)
+	}
+
+	var manifest assetid.AssetManifest
+	if err := json.Unmarshal(objects[index[assetid.ManifestFile]], &manifest); err != nil {
+		t.Fatalf("Failed to decode manifest through the index: %v", err)
+	}
+
+	// identical content is stored once
+	app, copied := index[manifest.Assets["js/app.js"]], index[manifest.Assets["js/copy.js"]]
+	if app == "" || app != copied {
+		t.Errorf("Objects for identical files = %q and %q, want one shared object", app, copied)
+	}
+	if !strings.HasPrefix(app, "objects/") || !strings.HasSuffix(app, ".js") {
+		t.Errorf("Object path = %q, want objects/<digest>.js", app)
+	}
+	// one object per distinct file, plus the index
+	if len(objects) != len(index) {
+		t.Errorf("Stored %d objects for %d files, want identical files stored once", len(objects)-1, len(index))
+	}
+}
@@ -0,0 +1,112 @@
+package build
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// prepareOutput removes a directory output before the build, unless previous
+// builds are retained, or points an atomic build at a staging directory
+func (b *builder) prepareOutput() error {
+	dir, isDir := b.out.(*DirOutput)
+	switch {
+	case b.opts.Retention.enabled() && !isDir:
+		return errors.New("retaining previous builds requires a directory output")
+	case b.opts.Atomic && !isDir:
+		return errors.New("atomic builds require a directory output")
+	case b.opts.Atomic && b.opts.Retention.enabled():
+		return errors.New("atomic builds cannot retain previous builds")
+	case !isDir:
+		return nil
+	}
+	b.dir = dir
+	// files of retained builds are only replaced once this build succeeds
+	dir.begin(b.opts.Retention.enabled())
+
+	if b.opts.Atomic {
+		// the staging directory is a sibling of the output so publishing it is a rename
+		parent := filepath.Dir(dir.Path())
+		if err := os.MkdirAll(parent, 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+		staging, err := os.MkdirTemp(parent, "."+filepath.Base(dir.Path())+"-")
+		if err != nil {
+			return fmt.Errorf("failed to create staging directory: %w", err)
+		}
+		// MkdirTemp creates the directory private to its owner; the published
+		// output keeps the previous output's mode, or that of a new directory
+		mode := os.FileMode(0755)
+		if info, err := os.Stat(dir.Path()); err == nil && info.IsDir() {
+			mode = info.Mode().Perm()
+		}
+		if err := os.Chmod(staging, mode); err != nil {
+			_ = os.RemoveAll(staging)
+			return fmt.Errorf("failed to create staging directory: %w", err)
+		}
+		b.staging = Dir(staging)
+		b.out = b.staging
+		return nil
+	}
+
+	// remove dist directory to ensure the only fingerprinted files are the one we need,
+	// unless previous builds are retained and pruned once this one is written
+	if !b.opts.Retention.enabled() {
+		if err := os.RemoveAll(dir.Path()); err != nil {
+			return fmt.Errorf("failed to remove dist directory: %w", err)
+		}
+	}
+	return nil
+}
+
+// abort removes the partial output of a failed build: the staging directory of
+// an atomic build, or the files a build wrote to its output directory, keeping
+// the files of retained builds unchanged
+func (b *builder) abort() {
+	if b.staging != nil {
+		_ = os.RemoveAll(b.staging.Path())
+		return
+	}
+	if b.dir != nil {
+		b.dir.discard()
+	}
+}
+
+// publish makes the output of a build visible. A build retaining previous
+// builds replaces their pages and manifests, then removes the files of builds
+// outside its retention policy. An atomic build replaces the output directory
+// with its staging directory; the previous output is moved aside first, as a
+// directory cannot be renamed over a non-empty one, and removed afterwards.
+func (b *builder) publish() error {
+	if b.staging == nil {
+		if b.dir == nil {
+			return nil
+		}
+		if err := b.dir.commit(); err != nil {
+			return fmt.Errorf("failed to publish build: %w", err)
+		}
+		if b.opts.Retention.enabled() {
+			return recordBuild(b.dir.Path(), b.manifest, b.opts.Retention, time.Now())
+		}
+		return nil
+	}
+	output, staging := b.dir.Path(), b.staging.Path()
+
+	previous := staging + ".previous"
+	if err := os.Rename(output, previous); err != nil && !errors.Is(err, os.ErrNotExist) {
+		_ = os.RemoveAll(staging)
+		return fmt.Errorf("failed to publish build: %w", err)
+	}
+	if err := os.Rename(staging, output); err != nil {
+		// put the previous output back so a failed publish changes nothing
+		_ = os.Rename(previous, output)
+		_ = os.RemoveAll(staging)
+		return fmt.Errorf("failed to publish build: %w", err)
+	}
+	if err := os.RemoveAll(previous); err != nil {
+		return fmt.Errorf("failed to remove previous output: %w", err)
+	}
+	return nil
+}
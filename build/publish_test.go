@@ -0,0 +1,273 @@
+package build
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jm96441n/assetid/assetid"
+	"github.com/jm96441n/assetid/transform"
+)
+
+// cancelAfter returns a transformer cancelling the build once it has seen n files
+func cancelAfter(n int, cancel context.CancelFunc) Transformer {
+	seen := 0
+	return Transformer{Match: transform.All(), Transformer: transform.Func(func(_ context.Context, file *transform.File) (*transform.Result, error) {
+		if seen++; seen == n {
+			cancel()
+		}
+		return &transform.Result{Data: file.Data}, nil
+	})}
+}
+
+// listFiles returns the files under dir, relative to it
+func listFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		relPath, err := filepath.Rel(dir, path)
+		files = append(files, filepath.ToSlash(relPath))
+		return err
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Failed to list %s: %v", dir, err)
+	}
+	return files
+}
+
+func TestBuildInterrupted(t *testing.T) {
+	sourceDir := writeSourceFiles(t, map[string]string{
+		"a.js":       "console.log('a');",
+		"b/b.js":     "console.log('b');",
+		"c/c.js":     "console.log('c');",
+		"index.html": `<script src="a.js"></script>`,
+	})
+
+	tests := []struct {
+		name string
+		opts Options
+		// previous is written to the output directory before the build
+		previous map[string]string
+		// want lists the files left in the output directory
+		want []string
+	}{
+		{
+			name: "partial output removed",
+		},
+		{
+			name:     "previous builds kept",
+			opts:     Options{Retention: Retention{Builds: 2}},
+			previous: map[string]string{"old-0123456789abcdef.js": "old", assetid.ManifestFile: "{}"},
+			want:     []string{assetid.ManifestFile, "old-0123456789abcdef.js"},
+		},
+		{
+			name:     "atomic build keeps previous output",
+			opts:     Options{Atomic: true},
+			previous: map[string]string{"old-0123456789abcdef.js": "old", assetid.ManifestFile: "{}"},
+			want:     []string{assetid.ManifestFile, "old-0123456789abcdef.js"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent := t.TempDir()
+			outputDir := filepath.Join(parent, "dist")
+			for name, content := range tt.previous {
+				if err := Dir(outputDir).WriteFile(name, []byte(content)); err != nil {
+					t.Fatalf("Failed to write previous output: %v", err)
+				}
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			opts := tt.opts
+			opts.SourceDir, opts.OutputDir = sourceDir, outputDir
+			opts.Transformers = []Transformer{cancelAfter(2, cancel)}
+			_, err := Build(ctx, opts)
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("Build error = %v, want %v", err, context.Canceled)
+			}
+
+			if got := listFiles(t, outputDir); !equalFiles(got, tt.want) {
+				t.Errorf("Output files = %v, want %v", got, tt.want)
+			}
+			if entries, _ := os.ReadDir(parent); len(entries) > 1 {
+				t.Errorf("Expected no staging directory to be left, got %v", entries)
+			}
+		})
+	}
+}
+
+// readFiles returns the content of every file under dir, keyed by relative path
+func readFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+
+	files := make(map[string]string)
+	for _, name := range listFiles(t, dir) {
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		files[name] = string(content)
+	}
+	return files
+}
+
+func TestBuildInterruptedAfterPages(t *testing.T) {
+	sourceDir := writeSourceFiles(t, map[string]string{
+		"app.js":     "console.log('v1');",
+		"index.html": `<script src="app.js"></script>`,
+	})
+	outputDir := filepath.Join(t.TempDir(), "dist")
+	opts := Options{SourceDir: sourceDir, OutputDir: outputDir, Retention: Retention{Builds: 2}}
+	if _, err := Build(context.Background(), opts); err != nil {
+		t.Fatalf("First build failed: %v", err)
+	}
+	previous := readFiles(t, outputDir)
+
+	if err := os.WriteFile(filepath.Join(sourceDir, "app.js"), []byte("console.log('v2');"), 0644); err != nil {
+		t.Fatalf("Failed to update source: %v", err)
+	}
+
+	// the build is canceled while its page is transformed, and stops before the manifest
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pageWritten := false
+	interrupted := opts
+	interrupted.Transformers = []Transformer{{Match: transform.MediaType("text/html"), Transformer: transform.Func(func(_ context.Context, file *transform.File) (*transform.Result, error) {
+		cancel()
+		return &transform.Result{Data: file.Data}, nil
+	})}}
+	interrupted.OnEvent = func(event Event) {
+		pageWritten = pageWritten || event.Kind == PageWritten
+	}
+	if _, err := Build(ctx, interrupted); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Build error = %v, want %v", err, context.Canceled)
+	}
+	if !pageWritten {
+		t.Fatal("Expected the page to be written before the build stopped")
+	}
+
+	if got := readFiles(t, outputDir); !reflect.DeepEqual(got, previous) {
+		t.Errorf("Output after interrupted build = %v, want the previous build %v", got, previous)
+	}
+
+	// the next build replaces the page and manifest and leaves no temporary files
+	result, err := Build(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	got := readFiles(t, outputDir)
+	var manifest assetid.AssetManifest
+	if err := json.Unmarshal([]byte(got[assetid.ManifestFile]), &manifest); err != nil {
+		t.Fatalf("Failed to parse manifest: %v", err)
+	}
+	if !reflect.DeepEqual(manifest.Assets, result.Manifest.Assets) {
+		t.Errorf("Manifest assets = %v, want %v", manifest.Assets, result.Manifest.Assets)
+	}
+	if !strings.Contains(got["index.html"], result.Manifest.Assets["app.js"]) {
+		t.Errorf("index.html = %q, want it to reference %s", got["index.html"], result.Manifest.Assets["app.js"])
+	}
+	for name := range got {
+		if strings.HasPrefix(path.Base(name), ".") {
+			t.Errorf("Temporary file %s left in the output", name)
+		}
+	}
+}
+
+func TestBuildAtomic(t *testing.T) {
+	parent := t.TempDir()
+	outputDir := filepath.Join(parent, "dist")
+	if err := Dir(outputDir).WriteFile("stale.js", []byte("stale")); err != nil {
+		t.Fatalf("Failed to write previous output: %v", err)
+	}
+
+	sourceDir := writeSourceFiles(t, map[string]string{"app.js": "console.log('app');"})
+	var staged string
+	opts := Options{
+		SourceDir: sourceDir,
+		OutputDir: outputDir,
+		Atomic:    true,
+		Transformers: []Transformer{{Match: transform.All(), Transformer: transform.Func(func(_ context.Context, file *transform.File) (*transform.Result, error) {
+			// the previous output is untouched while the build runs
+			if _, err := os.Stat(filepath.Join(outputDir, "stale.js")); err != nil {
+				staged = err.Error()
+			}
+			return &transform.Result{Data: file.Data}, nil
+		})}},
+	}
+	result, err := Build(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if staged != "" {
+		t.Errorf("Previous output changed during the build: %s", staged)
+	}
+
+	want := []string{result.Manifest.Assets["app.js"], assetid.ManifestFile}
+	if got := listFiles(t, outputDir); !equalFiles(got, want) {
+		t.Errorf("Output files = %v, want %v", got, want)
+	}
+	if entries, _ := os.ReadDir(parent); len(entries) != 1 {
+		t.Errorf("Expected only the output directory to be left, got %v", entries)
+	}
+
+	// the published directory is not left private to its owner, and a rebuild
+	// keeps the mode of the previous output
+	checkMode := func(dir string, want os.FileMode) {
+		t.Helper()
+		if _, err := Build(context.Background(), Options{SourceDir: sourceDir, OutputDir: dir, Atomic: true}); err != nil {
+			t.Fatalf("Build failed: %v", err)
+		}
+		info, err := os.Stat(dir)
+		if err != nil {
+			t.Fatalf("Failed to stat output directory: %v", err)
+		}
+		if got := info.Mode().Perm(); got != want {
+			t.Errorf("Output directory mode = %v, want %v", got, want)
+		}
+	}
+	freshDir := filepath.Join(t.TempDir(), "dist")
+	checkMode(freshDir, 0755)
+	if err := os.Chmod(freshDir, 0750); err != nil {
+		t.Fatalf("Failed to change output directory mode: %v", err)
+	}
+	checkMode(freshDir, 0750)
+
+	for _, opts := range []Options{
+		{SourceDir: sourceDir, Output: MapOutput{}, Atomic: true},
+		{SourceDir: sourceDir, OutputDir: outputDir, Atomic: true, Retention: Retention{Builds: 2}},
+	} {
+		if _, err := Build(context.Background(), opts); err == nil {
+			t.Errorf("Expected error for atomic build with %+v, got nil", opts)
+		}
+	}
+}
+
+// equalFiles reports whether two file lists have the same files, in any order
+func equalFiles(got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	seen := make(map[string]bool, len(got))
+	for _, file := range got {
+		seen[file] = true
+	}
+	for _, file := range want {
+		if !seen[file] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,132 @@
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jm96441n/assetid/assetid"
+)
+
+// Retention decides which previous builds keep their files in the output
+// directory, so pages rendered by old deployments can still load their assets.
+// A build is kept if it is one of the last Builds builds or younger than
+// MaxAge. The zero Retention keeps no previous builds.
+type Retention struct {
+	Builds int
+	MaxAge time.Duration
+}
+
+// enabled reports whether previous builds are retained at all
+func (p Retention) enabled() bool {
+	return p.Builds > 0 || p.MaxAge > 0
+}
+
+// retain returns the builds kept by the policy at now; the latest build is always kept
+func (p Retention) retain(builds []assetid.BuildRecord, now time.Time) []assetid.BuildRecord {
+	var kept []assetid.BuildRecord
+	for i, build := range builds {
+		fromEnd := len(builds) - i
+		switch {
+		case fromEnd == 1,
+			p.Builds > 0 && fromEnd <= p.Builds,
+			p.MaxAge > 0 && now.Sub(build.Time) <= p.MaxAge:
+			kept = append(kept, build)
+		}
+	}
+	return kept
+}
+
+// recordBuild adds the files of the build just written to the output
+// directory's history, then prunes files from builds the policy no longer keeps
+func recordBuild(outputDir string, manifest assetid.AssetManifest, policy Retention, now time.Time) error {
+	history, err := assetid.ReadHistory(os.DirFS(outputDir))
+	if err != nil {
+		return fmt.Errorf("failed to read build history: %w", err)
+	}
+
+	files := make([]string, 0, len(manifest.Assets))
+	for _, fingerprinted := range manifest.Assets {
+		files = append(files, fingerprinted)
+	}
+	slices.Sort(files)
+
+	history.Builds = append(history.Builds, assetid.BuildRecord{Time: now, Files: files})
+	_, err = CollectGarbage(outputDir, history, policy, now, false)
+	return err
+}
+
+// CollectGarbage applies the policy to the history, removes every file in the
+// output directory that no retained build wrote, and saves the pruned history.
+// It returns the removed files; with dryRun nothing is changed on disk.
+func CollectGarbage(outputDir string, history assetid.BuildHistory, policy Retention, now time.Time, dryRun bool) ([]string, error) {
+	history.Builds = policy.retain(history.Builds, now)
+	keep := history.Files()
+
+	var removed, dirs []string
+	err := filepath.WalkDir(outputDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(outputDir, path)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path: %w", err)
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if entry.IsDir() {
+			if relPath != "." {
+				dirs = append(dirs, path)
+			}
+			return nil
+		}
+		if relPath == assetid.ManifestFile || relPath == assetid.HistoryFile || isExportFile(relPath) || keep[relPath] {
+			return nil
+		}
+
+		removed = append(removed, relPath)
+		if dryRun {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return removed, nil
+	}
+
+	// remove directories left empty, deepest first; non-empty ones fail and are kept
+	slices.SortFunc(dirs, func(a, b string) int {
+		return strings.Count(b, string(filepath.Separator)) - strings.Count(a, string(filepath.Separator))
+	})
+	for _, dir := range dirs {
+		_ = os.Remove(dir)
+	}
+
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode build history: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, assetid.HistoryFile), append(data, '\n'), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write build history: %w", err)
+	}
+	return removed, nil
+}
+
+// isExportFile reports whether relPath is a manifest written by --export
+func isExportFile(relPath string) bool {
+	return slices.ContainsFunc(assetid.ExportFormats(), func(format assetid.ManifestFormat) bool {
+		return format.File() == relPath
+	})
+}
@@ -0,0 +1,43 @@
+package build
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jm96441n/assetid/assetid"
+)
+
+func TestRetentionRetain(t *testing.T) {
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	builds := []assetid.BuildRecord{
+		{Time: now.Add(-72 * time.Hour), Files: []string{"a"}},
+		{Time: now.Add(-36 * time.Hour), Files: []string{"b"}},
+		{Time: now.Add(-12 * time.Hour), Files: []string{"c"}},
+		{Time: now.Add(-1 * time.Hour), Files: []string{"d"}},
+	}
+
+	tests := []struct {
+		name   string
+		policy Retention
+		want   []string
+	}{
+		{name: "latest only", policy: Retention{}, want: []string{"d"}},
+		{name: "last two builds", policy: Retention{Builds: 2}, want: []string{"c", "d"}},
+		{name: "more builds than exist", policy: Retention{Builds: 10}, want: []string{"a", "b", "c", "d"}},
+		{name: "last day", policy: Retention{MaxAge: 24 * time.Hour}, want: []string{"c", "d"}},
+		{name: "either condition", policy: Retention{Builds: 1, MaxAge: 48 * time.Hour}, want: []string{"b", "c", "d"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, build := range tt.policy.retain(builds, now) {
+				got = append(got, build.Files...)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("retain() kept %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Exit codes shared by every command
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+	// exitInterrupted follows the shell convention of 128 plus SIGINT
+	exitInterrupted = 130
+)
+
+// command is a subcommand of the assetid CLI with its own flag set
+type command struct {
+	name     string
+	summary  string
+	args     string
+	examples []string
+	// setup registers the command's flags and returns the function that runs it
+	setup func(flags *flag.FlagSet) runFunc
+}
+
+// runFunc runs a command with its remaining positional arguments until ctx is done
+type runFunc func(ctx context.Context, args []string, stdout io.Writer) error
+
+// usageError reports invalid command-line input, exiting with exitUsage
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+// newUsageError returns a usageError with a formatted message
+func newUsageError(format string, args ...any) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// defaultCommand runs when the first argument is a flag or there are no arguments
+const defaultCommand = "build"
+
+// commands returns every subcommand in the order they are listed in help output
+func commands() []*command {
+	return []*command{
+		buildCommand(),
+		watchCommand(),
+		serveCommand(),
+		verifyCommand(),
+		diffCommand(),
+		gcCommand(),
+		inspectCommand(),
+		genEmbedCommand(),
+		genGoCommand(),
+		versionCommand(),
+	}
+}
+
+// run parses args, runs the selected command and returns the process exit
+// code. Commands stop when ctx is done, which main ties to SIGINT and SIGTERM.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	name := defaultCommand
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	} else if len(args) > 0 && (args[0] == "-h" || args[0] == "-help" || args[0] == "--help") {
+		name = "help"
+	}
+
+	if name == "help" {
+		if len(args) > 0 {
+			if cmd := findCommand(args[0]); cmd != nil {
+				flags := newFlagSet(cmd, stdout)
+				cmd.setup(flags)
+				flags.Usage()
+				return exitOK
+			}
+		}
+		printUsage(stdout)
+		return exitOK
+	}
+
+	cmd := findCommand(name)
+	if cmd == nil {
+		fmt.Fprintf(stderr, "assetid: unknown command %q\n\n", name)
+		printUsage(stderr)
+		return exitUsage
+	}
+
+	flags := newFlagSet(cmd, stderr)
+	runCmd := cmd.setup(flags)
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	if err := runCmd(ctx, flags.Args(), stdout); err != nil {
+		if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+			fmt.Fprintf(stderr, "assetid %s: interrupted\n", cmd.name)
+			return exitInterrupted
+		}
+		fmt.Fprintf(stderr, "assetid %s: %v\n", cmd.name, err)
+
+		var usageErr *usageError
+		if errors.As(err, &usageErr) {
+			fmt.Fprintf(stderr, "Run 'assetid %s --help' for usage.\n", cmd.name)
+			return exitUsage
+		}
+		return exitFailure
+	}
+	return exitOK
+}
+
+// findCommand returns the command with the given name, or nil
+func findCommand(name string) *command {
+	for _, cmd := range commands() {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// newFlagSet returns a flag set for cmd whose help output lists its examples
+func newFlagSet(cmd *command, output io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet("assetid "+cmd.name, flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.Usage = func() {
+		fmt.Fprintf(output, "Usage: assetid %s %s\n\n%s\n", cmd.name, cmd.args, cmd.summary)
+
+		hasFlags := false
+		flags.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintf(output, "\nFlags:\n")
+			flags.PrintDefaults()
+		}
+
+		if len(cmd.examples) > 0 {
+			fmt.Fprintf(output, "\nExamples:\n")
+			for _, example := range cmd.examples {
+				fmt.Fprintf(output, "  %s\n", example)
+			}
+		}
+	}
+	return flags
+}
+
+// printUsage writes the top-level help listing every command
+func printUsage(output io.Writer) {
+	fmt.Fprintf(output, "Usage: assetid <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands() {
+		fmt.Fprintf(output, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(output, `
+Running assetid without a command is the same as "assetid %s".
+Run "assetid help <command>" or "assetid <command> --help" for details.
+
+Exit codes: %d success, %d failure, %d invalid usage, %d interrupted.
+`, defaultCommand, exitOK, exitFailure, exitUsage, exitInterrupted)
+}
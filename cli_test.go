@@ -0,0 +1,491 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jm96441n/assetid/assetid"
+	"github.com/jm96441n/assetid/build"
+)
+
+// processAssets builds sourceDir into outputDir with the default configuration
+func processAssets(sourceDir, outputDir string, shouldMinify bool) error {
+	_, err := build.Build(context.Background(), build.Options{SourceDir: sourceDir, OutputDir: outputDir, Minify: shouldMinify})
+	return err
+}
+
+// writeSourceFiles writes files under a new temporary source directory
+func writeSourceFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	sourceDir := t.TempDir()
+	for path, content := range files {
+		fullPath := filepath.Join(sourceDir, path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", fullPath, err)
+		}
+	}
+	return sourceDir
+}
+
+func TestRun(t *testing.T) {
+	sourceDir := writeSourceFiles(t, map[string]string{"app.js": "console.log('app');"})
+
+	tests := []struct {
+		name       string
+		args       func(outputDir string) []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+		wantBuild  bool
+	}{
+		{
+			name:      "bare command builds",
+			args:      func(outputDir string) []string { return []string{"--source", sourceDir, "--output", outputDir} },
+			wantCode:  exitOK,
+			wantBuild: true,
+		},
+		{
+			name: "build command",
+			args: func(outputDir string) []string {
+				return []string{"build", "--source", sourceDir, "--output", outputDir}
+			},
+			wantCode:  exitOK,
+			wantBuild: true,
+		},
+		{
+			name:       "missing source",
+			args:       func(outputDir string) []string { return []string{"build", "--output", outputDir} },
+			wantCode:   exitUsage,
+			wantStderr: "--source is required",
+		},
+		{
+			name:       "unknown flag",
+			args:       func(outputDir string) []string { return []string{"build", "--nope"} },
+			wantCode:   exitUsage,
+			wantStderr: "flag provided but not defined",
+		},
+		{
+			name: "unknown export format",
+			args: func(outputDir string) []string {
+				return []string{"build", "--source", sourceDir, "--output", outputDir, "--export", "vite,parcel"}
+			},
+			wantCode:   exitUsage,
+			wantStderr: `unknown manifest format "parcel"`,
+		},
+		{
+			name:       "unknown command",
+			args:       func(outputDir string) []string { return []string{"nope"} },
+			wantCode:   exitUsage,
+			wantStderr: `unknown command "nope"`,
+		},
+		{
+			name: "build failure",
+			args: func(outputDir string) []string {
+				return []string{"--source", filepath.Join(sourceDir, "missing"), "--output", outputDir}
+			},
+			wantCode:   exitFailure,
+			wantStderr: "failed to process assets",
+		},
+		{
+			name:       "top-level help",
+			args:       func(outputDir string) []string { return []string{"--help"} },
+			wantCode:   exitOK,
+			wantStdout: "Commands:",
+		},
+		{
+			name:       "help for command",
+			args:       func(outputDir string) []string { return []string{"help", "watch"} },
+			wantCode:   exitOK,
+			wantStdout: "assetid watch --source ./src/assets --output ./dist",
+		},
+		{
+			name:       "command help flag",
+			args:       func(outputDir string) []string { return []string{"build", "--help"} },
+			wantCode:   exitOK,
+			wantStderr: "Examples:",
+		},
+		{
+			name:       "version",
+			args:       func(outputDir string) []string { return []string{"version"} },
+			wantCode:   exitOK,
+			wantStdout: "assetid ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputDir := filepath.Join(t.TempDir(), "dist")
+			var stdout, stderr bytes.Buffer
+
+			code := run(context.Background(), tt.args(outputDir), &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Errorf("run() = %d, want %d (stderr: %s)", code, tt.wantCode, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.wantStdout) {
+				t.Errorf("stdout = %q, want it to contain %q", stdout.String(), tt.wantStdout)
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr.String(), tt.wantStderr)
+			}
+
+			_, err := os.Stat(filepath.Join(outputDir, "manifest.json"))
+			if built := err == nil; built != tt.wantBuild {
+				t.Errorf("manifest written = %v, want %v", built, tt.wantBuild)
+			}
+		})
+	}
+}
+
+func TestRunInterrupted(t *testing.T) {
+	sourceDir := writeSourceFiles(t, map[string]string{"app.js": "console.log('app');"})
+	outputDir := filepath.Join(t.TempDir(), "dist")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var stdout, stderr bytes.Buffer
+	code := run(ctx, []string{"build", "--source", sourceDir, "--output", outputDir}, &stdout, &stderr)
+	if code != exitInterrupted {
+		t.Errorf("run() = %d, want %d (stderr: %s)", code, exitInterrupted, stderr.String())
+	}
+	if want := "assetid build: interrupted"; !strings.Contains(stderr.String(), want) {
+		t.Errorf("stderr = %q, want it to contain %q", stderr.String(), want)
+	}
+	if _, err := os.Stat(outputDir); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected no output directory after an interrupted build, got %v", err)
+	}
+}
+
+func TestBuildKeepGoing(t *testing.T) {
+	sourceDir := writeSourceFiles(t, map[string]string{
+		"app.js":    "console.log('app');",
+		"broken.js": "let x = ;",
+	})
+
+	tests := []struct {
+		name         string
+		keepGoing    bool
+		wantManifest bool
+	}{
+		{name: "keep going", keepGoing: true, wantManifest: true},
+		{name: "stop at first failure"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputDir := filepath.Join(t.TempDir(), "dist")
+			errorsPath := filepath.Join(t.TempDir(), "errors.json")
+			args := []string{"build", "--source", sourceDir, "--output", outputDir, "--minify", "--errors-json", errorsPath}
+			if tt.keepGoing {
+				args = append(args, "--keep-going")
+			}
+
+			var stdout, stderr bytes.Buffer
+			if code := run(context.Background(), args, &stdout, &stderr); code != exitFailure {
+				t.Errorf("run() = %d, want %d (stderr: %s)", code, exitFailure, stderr.String())
+			}
+
+			data, err := os.ReadFile(errorsPath)
+			if err != nil {
+				t.Fatalf("Failed to read errors: %v", err)
+			}
+			var fileErrs []build.FileError
+			if err := json.Unmarshal(data, &fileErrs); err != nil {
+				t.Fatalf("Failed to parse errors: %v", err)
+			}
+			if len(fileErrs) != 1 || fileErrs[0].Path != "broken.js" || fileErrs[0].Stage != build.StageTransform || fileErrs[0].Line != 1 {
+				t.Errorf("Errors = %+v, want a syntax error in broken.js", fileErrs)
+			}
+
+			manifest, err := assetid.ReadManifest(os.DirFS(outputDir), assetid.ManifestFile)
+			if built := err == nil; built != tt.wantManifest {
+				t.Fatalf("manifest written = %v, want %v", built, tt.wantManifest)
+			}
+			if tt.wantManifest && (!manifest.Incomplete || manifest.Assets["app.js"] == "") {
+				t.Errorf("Expected an incomplete manifest with app.js, got %+v", manifest)
+			}
+		})
+	}
+}
+
+func TestBuildExports(t *testing.T) {
+	sourceDir := writeSourceFiles(t, map[string]string{"app.js": "console.log('app');"})
+	outputDir := filepath.Join(t.TempDir(), "dist")
+
+	args := []string{"build", "--source", sourceDir, "--output", outputDir, "--export", "vite, mix", "--keep-builds", "2"}
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), args, &stdout, &stderr); code != exitOK {
+		t.Fatalf("run() = %d, want %d (stderr: %s)", code, exitOK, stderr.String())
+	}
+
+	for _, file := range []string{".vite/manifest.json", "mix-manifest.json"} {
+		if _, err := os.Stat(filepath.Join(outputDir, file)); err != nil {
+			t.Errorf("Expected %s to be written: %v", file, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "assets-manifest.json")); !os.IsNotExist(err) {
+		t.Errorf("Expected webpack manifest not to be written, got %v", err)
+	}
+
+	// the retained build's garbage collection must not remove the exports
+	if code := run(context.Background(), args, &stdout, &stderr); code != exitOK {
+		t.Fatalf("run() = %d, want %d (stderr: %s)", code, exitOK, stderr.String())
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, ".vite/manifest.json")); err != nil {
+		t.Errorf("Expected Vite manifest to survive a rebuild: %v", err)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	sourceDir := writeSourceFiles(t, map[string]string{
+		"app.js":    "console.log('app');",
+		"style.css": "body { color: red; }",
+	})
+	outputDir := filepath.Join(t.TempDir(), "dist")
+	if err := processAssets(sourceDir, outputDir, false); err != nil {
+		t.Fatalf("processAssets failed: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), []string{"inspect", "--output", outputDir, "--json", "style.css"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("inspect exited %d: %s", code, stderr.String())
+	}
+
+	var entries []inspectEntry
+	if err := json.Unmarshal(stdout.Bytes(), &entries); err != nil {
+		t.Fatalf("Failed to decode inspect output: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name != "style.css" || entries[0].Size != int64(len("body { color: red; }")) {
+		t.Errorf("Unexpected inspect output: %+v", entries)
+	}
+	if !strings.HasPrefix(entries[0].Fingerprinted, "style-") || entries[0].Integrity == "" {
+		t.Errorf("Unexpected inspect output: %+v", entries)
+	}
+
+	stdout.Reset()
+	if code := run(context.Background(), []string{"inspect", "--output", outputDir}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("inspect exited %d: %s", code, stderr.String())
+	}
+	if lines := strings.Split(strings.TrimSpace(stdout.String()), "\n"); len(lines) != 3 {
+		t.Errorf("Expected header and 2 assets, got:\n%s", stdout.String())
+	}
+
+	if code := run(context.Background(), []string{"inspect", "--output", outputDir, "missing.js"}, &stdout, &stderr); code != exitFailure {
+		t.Errorf("inspect of missing asset exited %d, want %d", code, exitFailure)
+	}
+}
+
+func TestWatchAssets(t *testing.T) {
+	sourceDir := writeSourceFiles(t, map[string]string{"app.js": "console.log('v1');"})
+	outputDir := filepath.Join(t.TempDir(), "dist")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	builds := make(chan error, 10)
+	done := make(chan error)
+	go func() {
+		opts := buildOptions{sourceDir: sourceDir, outputDir: outputDir}
+		done <- watchAssets(ctx, opts, 10*time.Millisecond, func(_ *build.Result, err error) { builds <- err })
+	}()
+
+	waitForBuild := func() {
+		t.Helper()
+		select {
+		case err := <-builds:
+			if err != nil {
+				t.Fatalf("Build failed: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for build")
+		}
+	}
+
+	// initial build
+	waitForBuild()
+
+	if err := os.WriteFile(filepath.Join(sourceDir, "app.js"), []byte("console.log('version 2');"), 0644); err != nil {
+		t.Fatalf("Failed to update source file: %v", err)
+	}
+	waitForBuild()
+
+	entries, err := inspectAssets(outputDir, []string{"app.js"})
+	if err != nil {
+		t.Fatalf("inspectAssets failed: %v", err)
+	}
+	if entries[0].Size != int64(len("console.log('version 2');")) {
+		t.Errorf("Rebuilt asset has size %d, want %d", entries[0].Size, len("console.log('version 2');"))
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("watchAssets returned %v", err)
+	}
+}
+
+func TestVerifyCommand(t *testing.T) {
+	sourceDir := writeSourceFiles(t, map[string]string{
+		"app.js":    "function app() { return 'app'; }",
+		"style.css": "body { color: red; }",
+	})
+	outputDir := filepath.Join(t.TempDir(), "dist")
+	if err := processAssets(sourceDir, outputDir, true); err != nil {
+		t.Fatalf("processAssets failed: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), []string{"verify", "--output", outputDir}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("verify exited %d: %s%s", code, stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "OK: 2 assets verified") {
+		t.Errorf("Unexpected verify output: %s", stdout.String())
+	}
+
+	// Leave an unreferenced fingerprinted file behind
+	orphan := filepath.Join(outputDir, "old-0123456789abcdef.js")
+	if err := os.WriteFile(orphan, []byte("old"), 0644); err != nil {
+		t.Fatalf("Failed to write orphan: %v", err)
+	}
+
+	stdout.Reset()
+	if code := run(context.Background(), []string{"verify", "--output", outputDir, "--format", "json"}, &stdout, &stderr); code != exitFailure {
+		t.Fatalf("verify exited %d, want %d", code, exitFailure)
+	}
+
+	var report struct {
+		Orphans []string `json:"orphans"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to decode verify output: %v", err)
+	}
+	if len(report.Orphans) != 1 || report.Orphans[0] != "old-0123456789abcdef.js" {
+		t.Errorf("Expected orphan in report, got %+v", report)
+	}
+}
+
+func TestDiffCommand(t *testing.T) {
+	oldOutput := filepath.Join(t.TempDir(), "dist")
+	if err := processAssets(writeSourceFiles(t, map[string]string{
+		"app.js":    "console.log('v1');",
+		"legacy.js": "console.log('legacy');",
+	}), oldOutput, false); err != nil {
+		t.Fatalf("processAssets failed: %v", err)
+	}
+
+	newOutput := filepath.Join(t.TempDir(), "dist")
+	if err := processAssets(writeSourceFiles(t, map[string]string{
+		"app.js": "console.log('v2');",
+		"new.js": "console.log('new');",
+	}), newOutput, false); err != nil {
+		t.Fatalf("processAssets failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "text from directories",
+			args: []string{"diff", oldOutput, newOutput},
+			want: []string{"added:   new.js", "removed: legacy.js", "changed: app.js", "1 added, 1 removed, 1 changed, 0 renamed, 0 unchanged"},
+		},
+		{
+			name: "markdown from manifest files",
+			args: []string{"diff", "--format", "markdown", filepath.Join(oldOutput, "manifest.json"), filepath.Join(newOutput, "manifest.json")},
+			want: []string{"## Asset changes", "### Added\n\n- `new.js`", "### Changed\n\n- `app.js`"},
+		},
+		{
+			name: "json",
+			args: []string{"diff", "--format", "json", oldOutput, newOutput},
+			want: []string{`"added": [`, `"asset": "legacy.js"`},
+		},
+		{
+			name: "purge urls",
+			args: []string{"diff", "--purge-base", "https://cdn.example.com/dist/", oldOutput, newOutput},
+			want: []string{"https://cdn.example.com/dist/app-", "https://cdn.example.com/dist/legacy-"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(context.Background(), tt.args, &stdout, &stderr); code != exitOK {
+				t.Fatalf("diff exited %d: %s", code, stderr.String())
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("Output missing %q:\n%s", want, stdout.String())
+				}
+			}
+		})
+	}
+
+	for _, args := range [][]string{
+		{"diff", oldOutput},
+		{"diff", "--format", "json", "--purge-base", "https://cdn.example.com/dist", oldOutput, newOutput},
+	} {
+		var stdout, stderr bytes.Buffer
+		if code := run(context.Background(), args, &stdout, &stderr); code != exitUsage {
+			t.Errorf("run(%q) = %d, want %d", args, code, exitUsage)
+		}
+	}
+}
+
+func TestBuildArchive(t *testing.T) {
+	sourceDir := writeSourceFiles(t, map[string]string{"app.js": "console.log('app');"})
+	archive := filepath.Join(t.TempDir(), "dist.tar.gz")
+
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), []string{"build", "--source", sourceDir, "--archive", archive}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("run() = %d, want %d (stderr: %s)", code, exitOK, stderr.String())
+	}
+
+	file, err := os.Open(archive)
+	if err != nil {
+		t.Fatalf("Failed to open archive: %v", err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("Failed to read archive: %v", err)
+	}
+
+	var names []string
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read archive: %v", err)
+		}
+		names = append(names, header.Name)
+	}
+	if len(names) != 2 || !strings.HasPrefix(names[0], "app-") || names[1] != "manifest.json" {
+		t.Errorf("Archive entries = %v, want the fingerprinted app.js and manifest.json", names)
+	}
+
+	for _, args := range [][]string{
+		{"build", "--source", sourceDir, "--archive", "dist.rar"},
+		{"build", "--source", sourceDir, "--archive", archive, "--output", t.TempDir()},
+		{"build", "--source", sourceDir, "--archive", archive, "--keep-builds", "2"},
+	} {
+		if code := run(context.Background(), args, &stdout, &stderr); code != exitUsage {
+			t.Errorf("run(%v) = %d, want %d", args, code, exitUsage)
+		}
+	}
+}
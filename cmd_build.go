@@ -0,0 +1,313 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/jm96441n/assetid/assetid"
+	"github.com/jm96441n/assetid/build"
+)
+
+// buildOptions holds the flags shared by commands that run a build
+type buildOptions struct {
+	sourceDir string
+	outputDir string
+	minify    bool
+	retention build.Retention
+	// exports are the manifest formats written alongside manifest.json
+	exports []assetid.ManifestFormat
+	// configPath is the configuration file loaded into config by validate
+	configPath string
+	config     build.Config
+	// buildVersion is the version given to the banner template
+	buildVersion string
+	// htmlIntegrity adds integrity attributes to scripts and stylesheets referenced by HTML pages
+	htmlIntegrity bool
+	// atomic replaces the output directory only once the build succeeds
+	atomic bool
+	// archive is a .tar, .tar.gz, .tgz or .zip file written instead of the output directory
+	archive string
+	// keepGoing builds every file it can instead of stopping at the first failure
+	keepGoing bool
+	// errorsJSON is the file the failures of each build are written to as JSON
+	errorsJSON string
+}
+
+// register adds the build flags to flags
+func (o *buildOptions) register(flags *flag.FlagSet) {
+	flags.StringVar(&o.sourceDir, "source", "", "Source directory containing assets")
+	flags.StringVar(&o.outputDir, "output", "", "Directory to output fingerprinted assets")
+	flags.BoolVar(&o.minify, "minify", false, "Minify JS, HTML, SVG, JSON and XML files")
+	flags.StringVar(&o.configPath, "config", "", "JSON configuration file with minifier options and per-glob overrides")
+	registerRetention(flags, &o.retention)
+	flags.BoolVar(&o.atomic, "atomic", false, "Build into a staging directory and replace the output directory only if the build succeeds")
+	flags.BoolVar(&o.keepGoing, "keep-going", false, "Build every file that can be built, write a manifest marked incomplete and report all failures at the end")
+	flags.StringVar(&o.errorsJSON, "errors-json", "", "Write the files that failed to build to this file as JSON")
+	flags.StringVar(&o.buildVersion, "build-version", "", "Version of the build, available to the banner as {{.Version}}")
+	flags.BoolVar(&o.htmlIntegrity, "html-integrity", false, "Add integrity and crossorigin attributes to scripts and stylesheets referenced by HTML pages")
+	flags.Func("export", "Comma-separated manifest formats to write alongside manifest.json: vite, webpack, mix, sprockets", o.parseExports)
+}
+
+// parseExports parses the value of --export
+func (o *buildOptions) parseExports(value string) error {
+	for _, name := range strings.Split(value, ",") {
+		format := assetid.ManifestFormat(strings.TrimSpace(name))
+		if !slices.Contains(assetid.ExportFormats(), format) {
+			return fmt.Errorf("unknown manifest format %q", format)
+		}
+		if !slices.Contains(o.exports, format) {
+			o.exports = append(o.exports, format)
+		}
+	}
+	return nil
+}
+
+// registerRetention adds the flags of a retention policy to flags
+func registerRetention(flags *flag.FlagSet, policy *build.Retention) {
+	flags.IntVar(&policy.Builds, "keep-builds", 0, "Keep files from the last N builds in the output directory instead of deleting them")
+	flags.DurationVar(&policy.MaxAge, "keep-for", 0, "Keep files from builds younger than this duration, e.g. 48h")
+}
+
+// validate checks that the required build flags were given
+func (o *buildOptions) validate() error {
+	if o.sourceDir == "" {
+		return newUsageError("--source is required")
+	}
+	if o.outputDir == "" && o.archive == "" {
+		return newUsageError("--output is required")
+	}
+	if o.archive != "" {
+		if o.outputDir != "" {
+			return newUsageError("--output and --archive cannot be combined")
+		}
+		if o.atomic {
+			return newUsageError("--atomic cannot be used with --archive")
+		}
+		if archiveFormat(o.archive) == "" {
+			return newUsageError("--archive must end in .tar, .tar.gz, .tgz or .zip")
+		}
+		if o.retention.Builds > 0 || o.retention.MaxAge > 0 {
+			return newUsageError("--keep-builds and --keep-for cannot be used with --archive")
+		}
+	}
+	if o.retention.Builds < 0 || o.retention.MaxAge < 0 {
+		return newUsageError("--keep-builds and --keep-for must not be negative")
+	}
+	if o.atomic && (o.retention.Builds > 0 || o.retention.MaxAge > 0) {
+		return newUsageError("--atomic cannot be used with --keep-builds or --keep-for")
+	}
+
+	o.config = build.DefaultConfig()
+	if o.configPath != "" {
+		cfg, err := build.LoadConfig(o.configPath)
+		if err != nil {
+			return err
+		}
+		o.config = cfg
+	}
+	return nil
+}
+
+// options returns the build API options for the flags
+func (o *buildOptions) options() build.Options {
+	return build.Options{
+		SourceDir:     o.sourceDir,
+		OutputDir:     o.outputDir,
+		Minify:        o.minify,
+		Config:        &o.config,
+		Retention:     o.retention,
+		Atomic:        o.atomic,
+		KeepGoing:     o.keepGoing,
+		Exports:       o.exports,
+		Version:       o.buildVersion,
+		HTMLIntegrity: o.htmlIntegrity,
+		OnEvent: func(event build.Event) {
+			log.Printf("Processed: %s -> %s", event.Path, event.Output)
+		},
+	}
+}
+
+// build runs a build with the options, logging every file written and any
+// warnings. The result is returned with the error of a build that kept going.
+func (o *buildOptions) build(ctx context.Context) (*build.Result, error) {
+	if o.archive != "" {
+		return o.buildArchive(ctx)
+	}
+
+	result, err := build.Build(ctx, o.options())
+	if reportErr := o.reportErrors(err); reportErr != nil {
+		return nil, reportErr
+	}
+	if result == nil {
+		return nil, err
+	}
+
+	logWarnings(result)
+	if result.Manifest.Incomplete && o.atomic {
+		log.Printf("Output directory left unchanged: %s", o.outputDir)
+		return result, err
+	}
+	log.Printf("Asset manifest written to: %s", filepath.Join(o.outputDir, assetid.ManifestFile))
+	for _, format := range o.exports {
+		log.Printf("%s manifest written to: %s", format, filepath.Join(o.outputDir, filepath.FromSlash(format.File())))
+	}
+	return result, err
+}
+
+// buildArchive runs a build with the options into the archive file
+func (o *buildOptions) buildArchive(ctx context.Context) (result *build.Result, err error) {
+	file, err := os.Create(o.archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive: %w", err)
+	}
+	// finished is set once the archive is complete, even if the build is not
+	finished := false
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil && finished {
+			err, finished = fmt.Errorf("failed to write archive: %w", closeErr), false
+		}
+		if !finished {
+			os.Remove(o.archive)
+		}
+	}()
+
+	var output interface {
+		build.Output
+		Close() error
+	}
+	var gz *gzip.Writer
+	switch archiveFormat(o.archive) {
+	case "zip":
+		output = build.Zip(file)
+	case "tar.gz":
+		gz = gzip.NewWriter(file)
+		output = build.Tar(gz)
+	default:
+		output = build.Tar(file)
+	}
+
+	opts := o.options()
+	opts.Output = output
+	result, buildErr := build.Build(ctx, opts)
+	if reportErr := o.reportErrors(buildErr); reportErr != nil {
+		return nil, reportErr
+	}
+	if result == nil {
+		return nil, buildErr
+	}
+	if err := output.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return nil, fmt.Errorf("failed to write archive: %w", err)
+		}
+	}
+
+	logWarnings(result)
+	log.Printf("Archive written to: %s", o.archive)
+	finished = true
+	return result, buildErr
+}
+
+// archiveFormat returns the format of an archive file from its name: tar,
+// tar.gz or zip, or "" if it is not an archive
+func archiveFormat(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".tar"):
+		return "tar"
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return "tar.gz"
+	case strings.HasSuffix(lower, ".zip"):
+		return "zip"
+	}
+	return ""
+}
+
+// reportErrors logs a summary of the files that failed in a build that kept
+// going, grouped by stage, and writes them to --errors-json if given
+func (o *buildOptions) reportErrors(buildErr error) error {
+	fileErrs := build.FileErrors(buildErr)
+	if o.keepGoing && len(fileErrs) > 0 {
+		log.Printf("Build incomplete, failed files:")
+		for _, stage := range build.Stages() {
+			var messages []string
+			for _, fileErr := range fileErrs {
+				if fileErr.Stage == stage {
+					messages = append(messages, fileErr.Message)
+				}
+			}
+			if len(messages) > 0 {
+				log.Printf("  %s (%d):", stage, len(messages))
+			}
+			for _, message := range messages {
+				log.Printf("    %s", message)
+			}
+		}
+	}
+
+	if o.errorsJSON == "" {
+		return nil
+	}
+	if fileErrs == nil {
+		fileErrs = []build.FileError{}
+	}
+	data, err := json.MarshalIndent(fileErrs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode errors: %w", err)
+	}
+	if err := os.WriteFile(o.errorsJSON, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write errors: %w", err)
+	}
+	return nil
+}
+
+// logWarnings logs the warnings of a build
+func logWarnings(result *build.Result) {
+	for _, warning := range result.Warnings {
+		log.Printf("Warning: %s", warning)
+	}
+}
+
+func buildCommand() *command {
+	return &command{
+		name:    "build",
+		summary: "Fingerprint assets and write the manifest",
+		args:    "[flags]",
+		examples: []string{
+			"assetid build --source ./src/assets --output ./dist",
+			"assetid build --source ./src/assets --output ./dist --minify",
+			"assetid build --source ./src/assets --output ./dist --minify --config assetid.json",
+			"assetid build --source ./src/assets --output ./dist --keep-builds 3 --keep-for 48h",
+			"assetid build --source ./src/assets --output ./public/assets --export sprockets,vite",
+			"assetid build --source ./src/assets --archive dist.tar.gz --minify",
+			"assetid build --source ./src/assets --output ./dist --minify --keep-going --errors-json build-errors.json",
+		},
+		setup: func(flags *flag.FlagSet) runFunc {
+			var opts buildOptions
+			opts.register(flags)
+			flags.StringVar(&opts.archive, "archive", "", "Write the build to a .tar, .tar.gz, .tgz or .zip file instead of --output")
+
+			return func(ctx context.Context, args []string, stdout io.Writer) error {
+				if len(args) > 0 {
+					return newUsageError("unexpected arguments: %v", args)
+				}
+				if err := opts.validate(); err != nil {
+					return err
+				}
+				_, err := opts.build(ctx)
+				return err
+			}
+		},
+	}
+}
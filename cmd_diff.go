@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jm96441n/assetid/assetid"
+)
+
+func diffCommand() *command {
+	return &command{
+		name:    "diff",
+		summary: "Compare two manifests for release notes and CDN purges",
+		args:    "[flags] <old> <new>",
+		examples: []string{
+			"assetid diff ./previous/dist ./dist",
+			"assetid diff --format markdown previous-manifest.json dist/manifest.json",
+			"assetid diff --purge-base https://cdn.example.com/dist ./previous/dist ./dist",
+		},
+		setup: func(flags *flag.FlagSet) runFunc {
+			var (
+				format    string
+				purgeBase string
+			)
+			flags.StringVar(&format, "format", "text", "Output format: text, json or markdown")
+			flags.StringVar(&purgeBase, "purge-base", "", "Print only the URLs to purge under this base URL")
+
+			return func(ctx context.Context, args []string, stdout io.Writer) error {
+				if len(args) != 2 {
+					return newUsageError("expected two manifests or output directories, got %d arguments", len(args))
+				}
+				if format != "text" && format != "json" && format != "markdown" {
+					return newUsageError("unknown format %q", format)
+				}
+				if purgeBase != "" && format != "text" {
+					return newUsageError("--purge-base cannot be used with --format %s", format)
+				}
+
+				oldManifest, err := loadManifestArg(args[0])
+				if err != nil {
+					return err
+				}
+				newManifest, err := loadManifestArg(args[1])
+				if err != nil {
+					return err
+				}
+
+				diff := assetid.DiffManifests(oldManifest, newManifest)
+
+				switch {
+				case purgeBase != "":
+					base := strings.TrimSuffix(purgeBase, "/")
+					for _, file := range diff.StaleFiles() {
+						fmt.Fprintf(stdout, "%s/%s\n", base, file)
+					}
+				case format == "json":
+					encoder := json.NewEncoder(stdout)
+					encoder.SetIndent("", "  ")
+					return encoder.Encode(diff)
+				case format == "markdown":
+					writeDiffMarkdown(stdout, diff)
+				default:
+					writeDiffText(stdout, diff)
+				}
+				return nil
+			}
+		},
+	}
+}
+
+// loadManifestArg reads a manifest from a manifest file or an output directory containing one
+func loadManifestArg(path string) (assetid.AssetManifest, error) {
+	dir, name := filepath.Dir(path), filepath.Base(path)
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		dir, name = path, assetid.ManifestFile
+	}
+
+	manifest, err := assetid.ReadManifest(os.DirFS(dir), name)
+	if err != nil {
+		return assetid.AssetManifest{}, fmt.Errorf("failed to read manifest %s: %w", path, err)
+	}
+	return manifest, nil
+}
+
+// writeDiffText writes a diff as one line per asset
+func writeDiffText(w io.Writer, diff *assetid.ManifestDiff) {
+	for _, entry := range diff.Added {
+		fmt.Fprintf(w, "added:   %s -> %s\n", entry.Asset, entry.New)
+	}
+	for _, entry := range diff.Removed {
+		fmt.Fprintf(w, "removed: %s (%s)\n", entry.Asset, entry.Old)
+	}
+	for _, entry := range diff.Changed {
+		fmt.Fprintf(w, "changed: %s: %s -> %s\n", entry.Asset, entry.Old, entry.New)
+	}
+	for _, entry := range diff.Renamed {
+		fmt.Fprintf(w, "renamed: %s -> %s (%s)\n", entry.OldAsset, entry.Asset, entry.New)
+	}
+	fmt.Fprintf(w, "%d added, %d removed, %d changed, %d renamed, %d unchanged\n",
+		len(diff.Added), len(diff.Removed), len(diff.Changed), len(diff.Renamed), diff.Unchanged)
+}
+
+// writeDiffMarkdown writes a diff as Markdown suitable for release notes
+func writeDiffMarkdown(w io.Writer, diff *assetid.ManifestDiff) {
+	fmt.Fprintf(w, "## Asset changes\n\n")
+	if diff.Empty() {
+		fmt.Fprintf(w, "No asset changes.\n")
+		return
+	}
+
+	sections := []struct {
+		title   string
+		entries []assetid.DiffEntry
+		line    func(assetid.DiffEntry) string
+	}{
+		{"Added", diff.Added, func(e assetid.DiffEntry) string { return fmt.Sprintf("`%s`", e.Asset) }},
+		{"Removed", diff.Removed, func(e assetid.DiffEntry) string { return fmt.Sprintf("`%s`", e.Asset) }},
+		{"Changed", diff.Changed, func(e assetid.DiffEntry) string {
+			return fmt.Sprintf("`%s` (`%s` → `%s`)", e.Asset, e.Old, e.New)
+		}},
+		{"Renamed", diff.Renamed, func(e assetid.DiffEntry) string {
+			return fmt.Sprintf("`%s` → `%s`", e.OldAsset, e.Asset)
+		}},
+	}
+	for _, section := range sections {
+		if len(section.entries) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "### %s\n\n", section.title)
+		for _, entry := range section.entries {
+			fmt.Fprintf(w, "- %s\n", section.line(entry))
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "%d assets unchanged.\n", diff.Unchanged)
+}
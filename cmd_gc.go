@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/jm96441n/assetid/assetid"
+	"github.com/jm96441n/assetid/build"
+)
+
+func gcCommand() *command {
+	return &command{
+		name:    "gc",
+		summary: "Remove files from builds outside the retention policy",
+		args:    "[flags]",
+		examples: []string{
+			"assetid gc --output ./dist --keep-builds 3",
+			"assetid gc --output ./dist --keep-for 48h --dry-run",
+		},
+		setup: func(flags *flag.FlagSet) runFunc {
+			var (
+				outputDir string
+				policy    build.Retention
+				dryRun    bool
+			)
+			flags.StringVar(&outputDir, "output", "", "Directory containing fingerprinted assets and the build history")
+			registerRetention(flags, &policy)
+			flags.BoolVar(&dryRun, "dry-run", false, "Print the files that would be removed without removing them")
+
+			return func(ctx context.Context, args []string, stdout io.Writer) error {
+				if len(args) > 0 {
+					return newUsageError("unexpected arguments: %v", args)
+				}
+				if outputDir == "" {
+					return newUsageError("--output is required")
+				}
+				if policy.Builds < 0 || policy.MaxAge < 0 {
+					return newUsageError("--keep-builds and --keep-for must not be negative")
+				}
+				// without a policy, every build but the latest would be removed
+				if policy.Builds == 0 && policy.MaxAge == 0 {
+					return newUsageError("--keep-builds or --keep-for is required")
+				}
+
+				history, err := assetid.ReadHistory(os.DirFS(outputDir))
+				if err != nil {
+					return fmt.Errorf("failed to read build history: %w", err)
+				}
+				if len(history.Builds) == 0 {
+					return fmt.Errorf("no build history in %s; build with --keep-builds or --keep-for first", outputDir)
+				}
+
+				removed, err := build.CollectGarbage(outputDir, history, policy, time.Now(), dryRun)
+				if err != nil {
+					return err
+				}
+
+				verb := "removed"
+				if dryRun {
+					verb = "would remove"
+				}
+				for _, file := range removed {
+					fmt.Fprintf(stdout, "%s %s\n", verb, file)
+				}
+				fmt.Fprintf(stdout, "%s %d file(s)\n", verb, len(removed))
+				return nil
+			}
+		},
+	}
+}
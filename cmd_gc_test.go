@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jm96441n/assetid/assetid"
+	"github.com/jm96441n/assetid/build"
+)
+
+func TestBuildRetainsPreviousBuilds(t *testing.T) {
+	sourceDir := t.TempDir()
+	outputDir := filepath.Join(t.TempDir(), "dist")
+	appPath := filepath.Join(sourceDir, "js", "app.js")
+	if err := os.MkdirAll(filepath.Dir(appPath), 0755); err != nil {
+		t.Fatalf("Failed to create source dir: %v", err)
+	}
+
+	opts := build.Options{SourceDir: sourceDir, OutputDir: outputDir, Retention: build.Retention{Builds: 2}}
+
+	var fingerprints []string
+	for _, version := range []string{"v1", "v2", "v3"} {
+		if err := os.WriteFile(appPath, []byte("console.log('"+version+"');"), 0644); err != nil {
+			t.Fatalf("Failed to write source: %v", err)
+		}
+		if _, err := build.Build(context.Background(), opts); err != nil {
+			t.Fatalf("Build failed: %v", err)
+		}
+
+		manifest, err := assetid.ReadManifest(os.DirFS(outputDir), assetid.ManifestFile)
+		if err != nil {
+			t.Fatalf("Failed to read manifest: %v", err)
+		}
+		fingerprints = append(fingerprints, manifest.Assets["js/app.js"])
+	}
+
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(outputDir, name))
+		return err == nil
+	}
+	if exists(fingerprints[0]) {
+		t.Errorf("File from the oldest build %s was not pruned", fingerprints[0])
+	}
+	for _, name := range fingerprints[1:] {
+		if !exists(name) {
+			t.Errorf("File from a retained build %s was removed", name)
+		}
+	}
+
+	// Retained files are not orphans
+	report, err := assetid.Verify(os.DirFS(outputDir))
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if !report.OK() {
+		t.Errorf("Verify reported problems with retained builds: %+v", report)
+	}
+
+	// gc requires a policy rather than keeping only the latest build
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), []string{"gc", "--output", outputDir}, &stdout, &stderr); code != exitUsage {
+		t.Errorf("gc without a policy exited %d, want %d", code, exitUsage)
+	}
+	if !exists(fingerprints[1]) {
+		t.Errorf("gc without a policy removed %s", fingerprints[1])
+	}
+
+	if code := run(context.Background(), []string{"gc", "--output", outputDir, "--keep-builds", "1", "--dry-run"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("gc --dry-run exited %d: %s", code, stderr.String())
+	}
+	want := "would remove " + fingerprints[1] + "\nwould remove 1 file(s)\n"
+	if stdout.String() != want || !exists(fingerprints[1]) {
+		t.Errorf("gc --dry-run output %q, want %q without removing the file", stdout.String(), want)
+	}
+
+	stdout.Reset()
+	if code := run(context.Background(), []string{"gc", "--output", outputDir, "--keep-builds", "1"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("gc exited %d: %s", code, stderr.String())
+	}
+	if want := "removed " + fingerprints[1] + "\nremoved 1 file(s)\n"; stdout.String() != want {
+		t.Errorf("gc output %q, want %q", stdout.String(), want)
+	}
+	if exists(fingerprints[1]) || !exists(fingerprints[2]) {
+		t.Errorf("gc should remove %s and keep %s", fingerprints[1], fingerprints[2])
+	}
+
+	history, err := assetid.ReadHistory(os.DirFS(outputDir))
+	if err != nil {
+		t.Fatalf("ReadHistory failed: %v", err)
+	}
+	if len(history.Builds) != 1 {
+		t.Errorf("History has %d builds after gc, want 1", len(history.Builds))
+	}
+}
+
+func TestGCWithoutHistory(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), []string{"gc", "--output", t.TempDir(), "--keep-builds", "1"}, &stdout, &stderr); code != exitFailure {
+		t.Errorf("gc without history exited %d, want %d", code, exitFailure)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+)
+
+// embedTemplate is the Go source written by gen-embed
+var embedTemplate = template.Must(template.New("embed").Parse(`// Code generated by assetid gen-embed; DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"embed"
+	"io/fs"
+
+	"github.com/jm96441n/assetid/assetid"
+)
+
+//go:embed all:{{.Dir}}
+var {{.FilesVar}} embed.FS
+
+// {{.Var}} resolves and serves the fingerprinted assets embedded from {{.Dir}}
+var {{.Var}} = {{.LoadFunc}}()
+
+func {{.LoadFunc}}() *assetid.Loader {
+	sub, err := fs.Sub({{.FilesVar}}, "{{.Dir}}")
+	if err != nil {
+		panic(err)
+	}
+	loader, err := assetid.NewFSLoader(sub)
+	if err != nil {
+		panic(err)
+	}
+	return loader
+}
+`))
+
+// embedConfig holds the values substituted into embedTemplate
+type embedConfig struct {
+	Package  string
+	Dir      string
+	Var      string
+	FilesVar string
+	LoadFunc string
+}
+
+func genEmbedCommand() *command {
+	return &command{
+		name:    "gen-embed",
+		summary: "Generate a Go file that embeds an output directory",
+		args:    "[flags]",
+		examples: []string{
+			"assetid gen-embed --output ./web/dist --file ./web/assets_embed.go",
+			"assetid gen-embed --output ./web/dist --file ./web/assets_embed.go --package web --var Static",
+		},
+		setup: func(flags *flag.FlagSet) runFunc {
+			var (
+				outputDir string
+				goFile    string
+				pkg       string
+				varName   string
+			)
+			flags.StringVar(&outputDir, "output", "", "Directory containing fingerprinted assets and the manifest")
+			flags.StringVar(&goFile, "file", "assets_embed.go", "Path of the Go file to generate")
+			flags.StringVar(&pkg, "package", "", "Package name of the generated file (default: name of its directory)")
+			flags.StringVar(&varName, "var", "Assets", "Name of the generated package-level loader variable")
+
+			return func(ctx context.Context, args []string, stdout io.Writer) error {
+				if len(args) > 0 {
+					return newUsageError("unexpected arguments: %v", args)
+				}
+				if outputDir == "" {
+					return newUsageError("--output is required")
+				}
+
+				source, err := generateEmbedSource(outputDir, goFile, pkg, varName)
+				if err != nil {
+					return err
+				}
+
+				if err := os.WriteFile(goFile, source, 0644); err != nil {
+					return fmt.Errorf("failed to write %s: %w", goFile, err)
+				}
+				return nil
+			}
+		},
+	}
+}
+
+// generateEmbedSource renders the gen-embed Go file for embedding outputDir from goFile
+func generateEmbedSource(outputDir, goFile, pkg, varName string) ([]byte, error) {
+	if outputDir == "" {
+		return nil, fmt.Errorf("--output is required")
+	}
+
+	absGoFile, err := filepath.Abs(goFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", goFile, err)
+	}
+	absOutputDir, err := filepath.Abs(outputDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", outputDir, err)
+	}
+
+	// go:embed can only reach files in or below the package directory
+	dir, err := filepath.Rel(filepath.Dir(absGoFile), absOutputDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get relative path: %w", err)
+	}
+	dir = filepath.ToSlash(dir)
+	if dir == "." || dir == ".." || strings.HasPrefix(dir, "../") {
+		return nil, fmt.Errorf("output directory %s must be a subdirectory of %s", outputDir, filepath.Dir(goFile))
+	}
+
+	if pkg == "" {
+		pkg = filepath.Base(filepath.Dir(absGoFile))
+	}
+	if !token.IsIdentifier(pkg) {
+		return nil, fmt.Errorf("invalid package name %q", pkg)
+	}
+	if !token.IsIdentifier(varName) {
+		return nil, fmt.Errorf("invalid variable name %q", varName)
+	}
+
+	first, size := utf8.DecodeRuneInString(varName)
+	unexported := string(unicode.ToLower(first)) + varName[size:]
+
+	var buf bytes.Buffer
+	err = embedTemplate.Execute(&buf, embedConfig{
+		Package:  pkg,
+		Dir:      dir,
+		Var:      varName,
+		FilesVar: unexported + "Files",
+		LoadFunc: "load" + string(unicode.ToUpper(first)) + varName[size:],
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render embed file: %w", err)
+	}
+
+	return format.Source(buf.Bytes())
+}
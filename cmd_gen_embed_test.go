@@ -0,0 +1,98 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateEmbedSource(t *testing.T) {
+	baseDir := t.TempDir()
+	pkgDir := filepath.Join(baseDir, "web")
+	outputDir := filepath.Join(pkgDir, "static", "dist")
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		t.Fatalf("Failed to create output dir: %v", err)
+	}
+
+	source, err := generateEmbedSource(outputDir, filepath.Join(pkgDir, "assets_embed.go"), "", "Assets")
+	if err != nil {
+		t.Fatalf("generateEmbedSource failed: %v", err)
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), "assets_embed.go", source, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Generated source does not parse: %v\n%s", err, source)
+	}
+
+	if file.Name.Name != "web" {
+		t.Errorf("Expected package web, got %s", file.Name.Name)
+	}
+
+	for _, want := range []string{
+		"//go:embed all:static/dist\n",
+		"var assetsFiles embed.FS",
+		"var Assets = loadAssets()",
+		`fs.Sub(assetsFiles, "static/dist")`,
+		"assetid.NewFSLoader(sub)",
+	} {
+		if !strings.Contains(string(source), want) {
+			t.Errorf("Generated source missing %q:\n%s", want, source)
+		}
+	}
+}
+
+func TestGenerateEmbedSourceErrors(t *testing.T) {
+	baseDir := t.TempDir()
+
+	tests := []struct {
+		name      string
+		outputDir string
+		goFile    string
+		pkg       string
+		varName   string
+	}{
+		{
+			name:      "missing output",
+			outputDir: "",
+			goFile:    filepath.Join(baseDir, "assets_embed.go"),
+			varName:   "Assets",
+		},
+		{
+			name:      "output outside package",
+			outputDir: filepath.Join(baseDir, "dist"),
+			goFile:    filepath.Join(baseDir, "web", "assets_embed.go"),
+			varName:   "Assets",
+		},
+		{
+			name:      "output is package directory",
+			outputDir: baseDir,
+			goFile:    filepath.Join(baseDir, "assets_embed.go"),
+			varName:   "Assets",
+		},
+		{
+			name:      "invalid package",
+			outputDir: filepath.Join(baseDir, "dist"),
+			goFile:    filepath.Join(baseDir, "assets_embed.go"),
+			pkg:       "my-assets",
+			varName:   "Assets",
+		},
+		{
+			name:      "invalid variable",
+			outputDir: filepath.Join(baseDir, "dist"),
+			goFile:    filepath.Join(baseDir, "assets_embed.go"),
+			pkg:       "assets",
+			varName:   "1Assets",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := generateEmbedSource(tt.outputDir, tt.goFile, tt.pkg, tt.varName); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/jm96441n/assetid/assetid"
+)
+
+// goConstantsTemplate is the Go source written by gen-go
+var goConstantsTemplate = template.Must(template.New("gen-go").Parse(`// Code generated by assetid gen-go; DO NOT EDIT.
+
+package {{.Package}}
+
+import "github.com/jm96441n/assetid/assetid"
+
+var (
+{{- range .Assets}}
+	// {{.Ident}} is {{.Asset.Name}}
+	{{.Ident}} = assetid.Asset{
+		Name:      {{printf "%q" .Asset.Name}},
+		URL:       {{printf "%q" .Asset.URL}},
+		Integrity: {{printf "%q" .Asset.Integrity}},
+		Size:      {{.Asset.Size}},
+	}
+{{- end}}
+)
+`))
+
+// goAsset is an asset rendered into goConstantsTemplate
+type goAsset struct {
+	Ident string
+	Asset assetid.Asset
+}
+
+func genGoCommand() *command {
+	return &command{
+		name:    "gen-go",
+		summary: "Generate a Go file with a typed variable per asset",
+		args:    "[flags]",
+		examples: []string{
+			"assetid gen-go --output ./dist --package assets --file ./assets/assets_gen.go",
+			"//go:generate go run github.com/jm96441n/assetid gen-go --output ../dist --package assets --file assets_gen.go",
+		},
+		setup: func(flags *flag.FlagSet) runFunc {
+			var (
+				outputDir string
+				goFile    string
+				pkg       string
+			)
+			flags.StringVar(&outputDir, "output", "", "Directory containing fingerprinted assets and the manifest")
+			flags.StringVar(&goFile, "file", "assets_gen.go", "Path of the Go file to generate")
+			flags.StringVar(&pkg, "package", "assets", "Package name of the generated file")
+
+			return func(ctx context.Context, args []string, stdout io.Writer) error {
+				if len(args) > 0 {
+					return newUsageError("unexpected arguments: %v", args)
+				}
+				if outputDir == "" {
+					return newUsageError("--output is required")
+				}
+				if !token.IsIdentifier(pkg) {
+					return newUsageError("invalid package name %q", pkg)
+				}
+
+				loader, err := assetid.NewLoader(os.DirFS(outputDir), assetid.ManifestFile)
+				if err != nil {
+					return fmt.Errorf("failed to load manifest: %w", err)
+				}
+
+				source, err := generateGoSource(loader, pkg)
+				if err != nil {
+					return err
+				}
+
+				if err := os.WriteFile(goFile, source, 0644); err != nil {
+					return fmt.Errorf("failed to write %s: %w", goFile, err)
+				}
+				return nil
+			}
+		},
+	}
+}
+
+// generateGoSource renders a Go file declaring an assetid.Asset variable for every asset of loader
+func generateGoSource(loader *assetid.Loader, pkg string) ([]byte, error) {
+	var assets []goAsset
+	seen := make(map[string]string)
+
+	for name := range loader.All() {
+		asset, ok := loader.Asset(name)
+		if !ok {
+			return nil, fmt.Errorf("failed to stat %s", name)
+		}
+
+		ident := assetIdent(name)
+		if other, ok := seen[ident]; ok {
+			return nil, fmt.Errorf("%s and %s both map to the identifier %s", other, name, ident)
+		}
+		seen[ident] = name
+
+		assets = append(assets, goAsset{Ident: ident, Asset: asset})
+	}
+
+	var buf bytes.Buffer
+	err := goConstantsTemplate.Execute(&buf, struct {
+		Package string
+		Assets  []goAsset
+	}{pkg, assets})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render Go file: %w", err)
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+// assetIdent derives an exported Go identifier from an asset path by joining
+// its words in title case and its extension in upper case, e.g.
+// img/logo.png -> ImgLogoPNG
+func assetIdent(name string) string {
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(stem, isIdentSeparator) {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	for _, word := range strings.FieldsFunc(ext, isIdentSeparator) {
+		b.WriteString(strings.ToUpper(word))
+	}
+
+	ident := b.String()
+	if ident == "" || !unicode.IsLetter([]rune(ident)[0]) {
+		ident = "Asset" + ident
+	}
+	return ident
+}
+
+// isIdentSeparator reports whether r cannot appear in a Go identifier
+func isIdentSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
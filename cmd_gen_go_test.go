@@ -0,0 +1,98 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jm96441n/assetid/assetid"
+)
+
+func TestGenerateGoSource(t *testing.T) {
+	fsys := fstest.MapFS{
+		"manifest.json": &fstest.MapFile{Data: []byte(`{
+			"assets": {
+				"app.js": "app-1234567890abcdef.js",
+				"img/logo.png": "img/logo-1234567890abcdef.png"
+			},
+			"integrity": {"app.js": "sha384-app"}
+		}`)},
+		"app-1234567890abcdef.js":       &fstest.MapFile{Data: []byte("console.log(1)")},
+		"img/logo-1234567890abcdef.png": &fstest.MapFile{Data: []byte("png")},
+	}
+	loader, err := assetid.NewLoader(fsys, assetid.ManifestFile)
+	if err != nil {
+		t.Fatalf("Failed to create loader: %v", err)
+	}
+
+	source, err := generateGoSource(loader, "assets")
+	if err != nil {
+		t.Fatalf("generateGoSource failed: %v", err)
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), "assets_gen.go", source, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Generated source does not parse: %v\n%s", err, source)
+	}
+	if file.Name.Name != "assets" {
+		t.Errorf("Expected package assets, got %s", file.Name.Name)
+	}
+
+	for _, want := range []string{
+		"// Code generated by assetid gen-go; DO NOT EDIT.",
+		"AppJS = assetid.Asset{",
+		`URL:       "/dist/app-1234567890abcdef.js"`,
+		`Integrity: "sha384-app"`,
+		"Size:      14,",
+		"ImgLogoPNG = assetid.Asset{",
+		`Name:      "img/logo.png"`,
+		`Integrity: "` + assetid.SRI([]byte("png")) + `"`,
+	} {
+		if !strings.Contains(string(source), want) {
+			t.Errorf("Generated source missing %q:\n%s", want, source)
+		}
+	}
+}
+
+func TestGenerateGoSourceCollision(t *testing.T) {
+	fsys := fstest.MapFS{
+		"manifest.json": &fstest.MapFile{Data: []byte(`{"assets": {
+			"app.js": "app-1234567890abcdef.js",
+			"app-.js": "app--1234567890abcdef.js"
+		}}`)},
+		"app-1234567890abcdef.js":  &fstest.MapFile{},
+		"app--1234567890abcdef.js": &fstest.MapFile{},
+	}
+	loader, err := assetid.NewLoader(fsys, assetid.ManifestFile)
+	if err != nil {
+		t.Fatalf("Failed to create loader: %v", err)
+	}
+
+	if _, err := generateGoSource(loader, "assets"); err == nil {
+		t.Error("Expected error for colliding identifiers, got nil")
+	}
+}
+
+func TestAssetIdent(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "app.js", want: "AppJS"},
+		{name: "img/logo.png", want: "ImgLogoPNG"},
+		{name: "css/site.min.css", want: "CssSiteMinCSS"},
+		{name: "fonts/open-sans_bold.woff2", want: "FontsOpenSansBoldWOFF2"},
+		{name: "404.html", want: "Asset404HTML"},
+		{name: "LICENSE", want: "LICENSE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := assetIdent(tt.name); got != tt.want {
+				t.Errorf("assetIdent(%q) = %s, want %s", tt.name, got, tt.want)
+			}
+		})
+	}
+}
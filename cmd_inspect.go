@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jm96441n/assetid/assetid"
+)
+
+// inspectEntry describes one asset in inspect output
+type inspectEntry struct {
+	Name          string `json:"name"`
+	Fingerprinted string `json:"fingerprinted"`
+	Size          int64  `json:"size"`
+	Integrity     string `json:"integrity,omitempty"`
+}
+
+func inspectCommand() *command {
+	return &command{
+		name:    "inspect",
+		summary: "List the assets in an output directory's manifest",
+		args:    "[flags] [asset...]",
+		examples: []string{
+			"assetid inspect --output ./dist",
+			"assetid inspect --output ./dist app.js css/site.css",
+			"assetid inspect --output ./dist --json",
+		},
+		setup: func(flags *flag.FlagSet) runFunc {
+			var (
+				outputDir string
+				asJSON    bool
+			)
+			flags.StringVar(&outputDir, "output", "", "Directory containing fingerprinted assets and the manifest")
+			flags.BoolVar(&asJSON, "json", false, "Print JSON instead of a table")
+
+			return func(ctx context.Context, args []string, stdout io.Writer) error {
+				if outputDir == "" {
+					return newUsageError("--output is required")
+				}
+
+				entries, err := inspectAssets(outputDir, args)
+				if err != nil {
+					return err
+				}
+
+				if asJSON {
+					encoder := json.NewEncoder(stdout)
+					encoder.SetIndent("", "  ")
+					return encoder.Encode(entries)
+				}
+
+				w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "ASSET\tFINGERPRINTED\tSIZE\tINTEGRITY")
+				for _, entry := range entries {
+					fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", entry.Name, entry.Fingerprinted, entry.Size, entry.Integrity)
+				}
+				return w.Flush()
+			}
+		},
+	}
+}
+
+// inspectAssets describes the named assets in outputDir, or all of them if names is empty
+func inspectAssets(outputDir string, names []string) ([]inspectEntry, error) {
+	loader, err := assetid.NewLoader(os.DirFS(outputDir), assetid.ManifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest: %w", err)
+	}
+
+	fingerprints := make(map[string]string)
+	for name, fingerprinted := range loader.All() {
+		fingerprints[name] = fingerprinted
+	}
+	if len(names) == 0 {
+		for name := range loader.All() {
+			names = append(names, name)
+		}
+	}
+
+	entries := make([]inspectEntry, 0, len(names))
+	for _, name := range names {
+		fingerprinted, ok := fingerprints[name]
+		if !ok {
+			return nil, fmt.Errorf("%s is not in the manifest", name)
+		}
+
+		info, err := loader.Stat(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect %s: %w", name, err)
+		}
+
+		entries = append(entries, inspectEntry{
+			Name:          name,
+			Fingerprinted: fingerprinted,
+			Size:          info.Size(),
+			Integrity:     loader.Integrity(name),
+		})
+	}
+	return entries, nil
+}
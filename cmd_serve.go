@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jm96441n/assetid/assetid"
+	"github.com/jm96441n/assetid/build"
+)
+
+func serveCommand() *command {
+	return &command{
+		name:    "serve",
+		summary: "Watch, rebuild and serve assets with live reload",
+		args:    "[flags]",
+		examples: []string{
+			"assetid serve --source ./src/assets --output ./dist",
+			"assetid serve --source ./src/assets --output ./dist --addr localhost:3000",
+		},
+		setup: func(flags *flag.FlagSet) runFunc {
+			var (
+				opts     buildOptions
+				addr     string
+				interval time.Duration
+			)
+			opts.register(flags)
+			flags.StringVar(&addr, "addr", "localhost:8080", "Address to listen on")
+			flags.DurationVar(&interval, "interval", 500*time.Millisecond, "How often to poll the source directory for changes")
+
+			return func(ctx context.Context, args []string, stdout io.Writer) error {
+				if len(args) > 0 {
+					return newUsageError("unexpected arguments: %v", args)
+				}
+				if err := opts.validate(); err != nil {
+					return err
+				}
+				if interval <= 0 {
+					return newUsageError("--interval must be positive")
+				}
+
+				return serveAssets(ctx, opts, addr, interval)
+			}
+		},
+	}
+}
+
+// serveAssets rebuilds on every change and serves the output directory with
+// live reload until ctx is done
+func serveAssets(ctx context.Context, opts buildOptions, addr string, interval time.Duration) error {
+	server := newDevServer(opts.outputDir)
+
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           server.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		// event streams end when the server's context is cancelled
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("Serving %s at http://%s", opts.outputDir, addr)
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- watchAssets(ctx, opts, interval, server.onBuild)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case err := <-watchErr:
+		if err != nil {
+			return err
+		}
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// devServer serves the latest build of an output directory with live reload
+type devServer struct {
+	outputDir string
+	broker    *reloadBroker
+	loader    atomic.Pointer[assetid.Loader]
+	// manifest is the manifest of the last successful build, used only by onBuild
+	manifest AssetManifest
+}
+
+func newDevServer(outputDir string) *devServer {
+	return &devServer{
+		outputDir: outputDir,
+		broker:    newReloadBroker(),
+	}
+}
+
+// onBuild reloads the manifest after a build and notifies connected pages of
+// the changes from the build's result
+func (s *devServer) onBuild(result *build.Result, buildErr error) {
+	if buildErr != nil {
+		s.broker.publishBuild(s.manifest, s.manifest, buildErr)
+		return
+	}
+
+	loader, err := assetid.NewLoader(os.DirFS(s.outputDir), assetid.ManifestFile)
+	if err != nil {
+		s.broker.publishBuild(s.manifest, s.manifest, fmt.Errorf("failed to load manifest: %w", err))
+		return
+	}
+
+	previous := s.manifest
+	s.manifest = result.Manifest
+	s.loader.Store(loader)
+
+	s.broker.publishBuild(previous, result.Manifest, nil)
+}
+
+// handler routes live reload requests, fingerprinted assets under /dist/ and
+// every other path to files in the output directory
+func (s *devServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(liveReloadEventsPath, s.broker)
+	mux.HandleFunc(assetid.LiveReloadPath, serveLiveReloadScript)
+	mux.HandleFunc("/dist/", func(w http.ResponseWriter, r *http.Request) {
+		loader := s.loader.Load()
+		if loader == nil {
+			http.Error(w, "waiting for the first build", http.StatusServiceUnavailable)
+			return
+		}
+		loader.UnhashedMiddleware(assetid.ServeUnhashed)(loader.Handler()).ServeHTTP(w, r)
+	})
+	mux.HandleFunc("/", s.servePage)
+	return mux
+}
+
+// servePage serves a file from the output directory by its original name, or
+// by its fingerprinted name as rewritten pages reference it, injecting the
+// live reload script into HTML pages
+func (s *devServer) servePage(w http.ResponseWriter, r *http.Request) {
+	loader := s.loader.Load()
+	if loader == nil {
+		http.Error(w, "waiting for the first build", http.StatusServiceUnavailable)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	if name == "" || strings.HasSuffix(name, "/") {
+		name += "index.html"
+	}
+
+	content, err := loader.ReadFile(name)
+	if original, ok := loader.Original(name); errors.Is(err, fs.ErrNotExist) && ok {
+		content, err = loader.ReadFile(original)
+	}
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if ext := path.Ext(name); ext == ".html" || ext == ".htm" {
+		content = injectLiveReload(content)
+	}
+
+	w.Header().Set("Cache-Control", "no-cache")
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(content))
+}
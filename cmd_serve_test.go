@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jm96441n/assetid/assetid"
+	"github.com/jm96441n/assetid/build"
+)
+
+// readEvent reads the next Server-Sent Event from r, skipping comments
+func readEvent(t *testing.T, r *bufio.Reader) (string, string) {
+	t.Helper()
+
+	var name, data string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && name != "":
+			return name, data
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestDevServer(t *testing.T) {
+	sourceDir := writeSourceFiles(t, map[string]string{
+		"index.html": "<html><body><h1>Hello</h1></body></html>",
+		"app.js":     "console.log('v1');",
+		"style.css":  "body { color: red; }",
+	})
+	outputDir := filepath.Join(t.TempDir(), "dist")
+
+	server := newDevServer(outputDir)
+	ts := httptest.NewServer(server.handler())
+	t.Cleanup(ts.Close)
+
+	get := func(path string) (*http.Response, string) {
+		t.Helper()
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("Failed to read body: %v", err)
+		}
+		return resp, string(body)
+	}
+
+	if resp, _ := get("/"); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("GET / before first build = %d, want %d", resp.StatusCode, http.StatusServiceUnavailable)
+	}
+
+	rebuild := func() {
+		t.Helper()
+		server.onBuild(build.Build(context.Background(), build.Options{SourceDir: sourceDir, OutputDir: outputDir}))
+	}
+	rebuild()
+
+	resp, body := get("/")
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, `<script src="`+assetid.LiveReloadPath+`"></script></body>`) {
+		t.Errorf("GET / = %d %q, want page with live reload script", resp.StatusCode, body)
+	}
+
+	if resp, body := get("/dist/app.js"); resp.StatusCode != http.StatusOK || body != "console.log('v1');" {
+		t.Errorf("GET /dist/app.js = %d %q", resp.StatusCode, body)
+	}
+
+	if resp, body := get(assetid.LiveReloadPath); resp.StatusCode != http.StatusOK || !strings.Contains(body, "EventSource") {
+		t.Errorf("GET %s = %d, want live reload script", assetid.LiveReloadPath, resp.StatusCode)
+	}
+
+	// Subscribe to live reload events
+	resp, err := http.Get(ts.URL + liveReloadEventsPath)
+	if err != nil {
+		t.Fatalf("Failed to connect to events: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", got)
+	}
+	events := bufio.NewReader(resp.Body)
+
+	// wait until the subscription is registered before publishing
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		server.broker.mu.Lock()
+		clients := len(server.broker.clients)
+		server.broker.mu.Unlock()
+		if clients > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for event subscription")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// A CSS-only change swaps stylesheets in place
+	if err := os.WriteFile(filepath.Join(sourceDir, "style.css"), []byte("body { color: blue; }"), 0644); err != nil {
+		t.Fatalf("Failed to update stylesheet: %v", err)
+	}
+	rebuild()
+
+	name, data := readEvent(t, events)
+	if name != eventCSS {
+		t.Fatalf("event = %q, want %q", name, eventCSS)
+	}
+	var changes []cssChange
+	if err := json.Unmarshal([]byte(data), &changes); err != nil {
+		t.Fatalf("Failed to decode css event: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Unhashed != "/dist/style.css" || changes[0].Old == changes[0].New {
+		t.Errorf("Unexpected css changes: %+v", changes)
+	}
+
+	// A JS change reloads the page
+	if err := os.WriteFile(filepath.Join(sourceDir, "app.js"), []byte("console.log('v2');"), 0644); err != nil {
+		t.Fatalf("Failed to update script: %v", err)
+	}
+	rebuild()
+
+	if name, _ := readEvent(t, events); name != eventReload {
+		t.Errorf("event = %q, want %q", name, eventReload)
+	}
+
+	// A failed build shows an overlay
+	server.onBuild(nil, errors.New("failed to minify source: unexpected ( on line 1 and column 10"))
+
+	name, data = readEvent(t, events)
+	if name != eventBuildError || !strings.Contains(data, "line 1 and column 10") {
+		t.Errorf("event = %q %q, want %q with the error message", name, data, eventBuildError)
+	}
+}
+
+func TestDevServerRewrittenPage(t *testing.T) {
+	sourceDir := writeSourceFiles(t, map[string]string{
+		"index.html":      `<html><head><link rel="stylesheet" href="css/style.css"><script src="/app.js"></script></head></html>`,
+		"docs/index.html": `<html><body><img src="../logo.svg"></body></html>`,
+		"app.js":          "console.log('app');",
+		"css/style.css":   "body { color: red; }",
+		"logo.svg":        "<svg></svg>",
+	})
+	outputDir := filepath.Join(t.TempDir(), "dist")
+
+	server := newDevServer(outputDir)
+	server.onBuild(build.Build(context.Background(), build.Options{SourceDir: sourceDir, OutputDir: outputDir}))
+	ts := httptest.NewServer(server.handler())
+	t.Cleanup(ts.Close)
+
+	get := func(path string) (int, string) {
+		t.Helper()
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("Failed to read body: %v", err)
+		}
+		return resp.StatusCode, string(body)
+	}
+	refs := regexp.MustCompile(`(?:src|href)="([^"]+)"`)
+
+	tests := []struct {
+		page string
+		want map[string]string
+	}{
+		{page: "/", want: map[string]string{"app.js": "console.log('app');", "css/style.css": "body { color: red; }"}},
+		{page: "/docs/", want: map[string]string{"logo.svg": "<svg></svg>"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.page, func(t *testing.T) {
+			status, page := get(tt.page)
+			if status != http.StatusOK {
+				t.Fatalf("GET %s = %d", tt.page, status)
+			}
+
+			got := map[string]string{}
+			for _, match := range refs.FindAllStringSubmatch(page, -1) {
+				ref := match[1]
+				if ref == assetid.LiveReloadPath {
+					continue
+				}
+				if !strings.HasPrefix(ref, "/") {
+					ref = path.Join(tt.page, ref)
+				}
+				status, body := get(ref)
+				if status != http.StatusOK {
+					t.Errorf("GET %s referenced by %s = %d", ref, tt.page, status)
+					continue
+				}
+				original, _ := server.loader.Load().Original(strings.TrimPrefix(ref, "/"))
+				got[original] = body
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("assets referenced by %s = %v, want %v", tt.page, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInjectLiveReload(t *testing.T) {
+	tag := `<script src="` + assetid.LiveReloadPath + `"></script>`
+
+	tests := []struct {
+		name string
+		page string
+		want string
+	}{
+		{name: "before body end", page: "<body><p>hi</p></BODY></html>", want: "<body><p>hi</p>" + tag + "</BODY></html>"},
+		{name: "fragment", page: "<p>hi</p>", want: "<p>hi</p>" + tag},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(injectLiveReload([]byte(tt.page))); got != tt.want {
+				t.Errorf("injectLiveReload() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jm96441n/assetid/assetid"
+)
+
+func verifyCommand() *command {
+	return &command{
+		name:    "verify",
+		summary: "Check an output directory against its manifest",
+		args:    "[flags]",
+		examples: []string{
+			"assetid verify --output ./dist",
+			"assetid verify --output ./dist --format json",
+		},
+		setup: func(flags *flag.FlagSet) runFunc {
+			var (
+				outputDir string
+				format    string
+			)
+			flags.StringVar(&outputDir, "output", "", "Directory containing fingerprinted assets and the manifest")
+			flags.StringVar(&format, "format", "text", "Output format: text or json")
+
+			return func(ctx context.Context, args []string, stdout io.Writer) error {
+				if len(args) > 0 {
+					return newUsageError("unexpected arguments: %v", args)
+				}
+				if outputDir == "" {
+					return newUsageError("--output is required")
+				}
+				if format != "text" && format != "json" {
+					return newUsageError("unknown format %q", format)
+				}
+
+				report, err := assetid.Verify(os.DirFS(outputDir))
+				if err != nil {
+					return err
+				}
+
+				if format == "json" {
+					encoder := json.NewEncoder(stdout)
+					encoder.SetIndent("", "  ")
+					if err := encoder.Encode(report); err != nil {
+						return err
+					}
+				} else {
+					writeVerifyReport(stdout, report)
+				}
+
+				if !report.OK() {
+					return fmt.Errorf("%d problems found in %s", report.Problems(), outputDir)
+				}
+				return nil
+			}
+		},
+	}
+}
+
+// writeVerifyReport writes a human-readable verify report
+func writeVerifyReport(w io.Writer, report *assetid.VerifyReport) {
+	if report.Incomplete {
+		fmt.Fprintln(w, "incomplete: the manifest was written by a build in which some files failed")
+	}
+	for _, problem := range report.Missing {
+		fmt.Fprintf(w, "missing: %s -> %s\n", problem.Asset, problem.File)
+	}
+	for _, problem := range report.HashMismatches {
+		fmt.Fprintf(w, "hash mismatch: %s -> %s has content hash %s\n", problem.Asset, problem.File, problem.Actual)
+	}
+	for _, problem := range report.IntegrityMismatches {
+		fmt.Fprintf(w, "integrity mismatch: %s -> %s is %s, manifest has %s\n", problem.Asset, problem.File, problem.Actual, problem.Expected)
+	}
+	for _, orphan := range report.Orphans {
+		fmt.Fprintf(w, "orphan: %s\n", orphan)
+	}
+
+	if report.OK() {
+		fmt.Fprintf(w, "OK: %d assets verified\n", report.Checked)
+	} else {
+		fmt.Fprintf(w, "FAILED: %d problems in %d assets\n", report.Problems(), report.Checked)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+)
+
+// version is the release version, set at build time with
+// -ldflags "-X main.version=v1.2.3"; otherwise it comes from the module build info
+var version = ""
+
+func versionCommand() *command {
+	return &command{
+		name:     "version",
+		summary:  "Print the assetid version",
+		args:     "",
+		examples: []string{"assetid version"},
+		setup: func(flags *flag.FlagSet) runFunc {
+			return func(ctx context.Context, args []string, stdout io.Writer) error {
+				if len(args) > 0 {
+					return newUsageError("unexpected arguments: %v", args)
+				}
+				_, err := fmt.Fprintf(stdout, "assetid %s %s/%s %s\n", buildVersion(), runtime.GOOS, runtime.GOARCH, runtime.Version())
+				return err
+			}
+		},
+	}
+}
+
+// buildVersion returns the version of the running binary
+func buildVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
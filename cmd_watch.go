@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"io/fs"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jm96441n/assetid/build"
+)
+
+// fileState is the part of a file's metadata that signals a change
+type fileState struct {
+	modTime int64
+	size    int64
+}
+
+func watchCommand() *command {
+	return &command{
+		name:    "watch",
+		summary: "Rebuild assets whenever the source directory changes",
+		args:    "[flags]",
+		examples: []string{
+			"assetid watch --source ./src/assets --output ./dist",
+			"assetid watch --source ./src/assets --output ./dist --interval 1s",
+		},
+		setup: func(flags *flag.FlagSet) runFunc {
+			var (
+				opts     buildOptions
+				interval time.Duration
+			)
+			opts.register(flags)
+			flags.DurationVar(&interval, "interval", 500*time.Millisecond, "How often to poll the source directory for changes")
+
+			return func(ctx context.Context, args []string, stdout io.Writer) error {
+				if len(args) > 0 {
+					return newUsageError("unexpected arguments: %v", args)
+				}
+				if err := opts.validate(); err != nil {
+					return err
+				}
+				if interval <= 0 {
+					return newUsageError("--interval must be positive")
+				}
+
+				return watchAssets(ctx, opts, interval, nil)
+			}
+		},
+	}
+}
+
+// watchAssets builds once, then polls the source directory, and the
+// dependencies the last build reported outside it, and rebuilds on every
+// change until ctx is done. Build errors are logged rather than returned so a
+// broken edit does not stop the watcher. onBuild, if set, is called with the
+// result and error of every build.
+func watchAssets(ctx context.Context, opts buildOptions, interval time.Duration, onBuild func(*build.Result, error)) error {
+	var dependencies []string
+	rebuild := func() {
+		result, err := opts.build(ctx)
+		if err != nil {
+			log.Printf("Build failed: %v", err)
+		}
+		// a failed build keeps watching the dependencies of the last one that finished
+		if result != nil {
+			dependencies = dependencyPaths(opts.sourceDir, result)
+		}
+		if onBuild != nil {
+			onBuild(result, err)
+		}
+	}
+
+	previous, err := snapshotSource(opts.sourceDir, opts.outputDir)
+	if err != nil {
+		return err
+	}
+	rebuild()
+	snapshotFiles(previous, dependencies, nil)
+	log.Printf("Watching %s for changes", opts.sourceDir)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		source, err := snapshotSource(opts.sourceDir, opts.outputDir)
+		if err != nil {
+			log.Printf("Failed to scan %s: %v", opts.sourceDir, err)
+			continue
+		}
+		current := maps.Clone(source)
+		snapshotFiles(current, dependencies, nil)
+		if maps.Equal(previous, current) {
+			continue
+		}
+
+		log.Printf("Change detected, rebuilding")
+		rebuild()
+		// the dependencies of this build are compared from their state before it,
+		// so edits made while it ran trigger another build
+		previous = source
+		snapshotFiles(previous, dependencies, current)
+	}
+}
+
+// dependencyPaths returns the paths of the dependencies of a build outside
+// sourceDir, as files inside it are already watched
+func dependencyPaths(sourceDir string, result *build.Result) []string {
+	var paths []string
+	for _, dependencies := range result.Dependencies {
+		for _, dependency := range dependencies {
+			if relPath := filepath.FromSlash(dependency); !filepath.IsLocal(relPath) {
+				paths = append(paths, filepath.Join(sourceDir, relPath))
+			}
+		}
+	}
+	slices.Sort(paths)
+	return slices.Compact(paths)
+}
+
+// snapshotFiles adds the state of each file in paths to snapshot, taken from
+// known if recorded there, with a zero state for missing files so their
+// creation is a change
+func snapshotFiles(snapshot map[string]fileState, paths []string, known map[string]fileState) {
+	for _, path := range paths {
+		if state, ok := known[path]; ok {
+			snapshot[path] = state
+			continue
+		}
+		var state fileState
+		if info, err := os.Stat(path); err == nil {
+			state = fileState{modTime: info.ModTime().UnixNano(), size: info.Size()}
+		}
+		snapshot[path] = state
+	}
+}
+
+// snapshotSource records the state of every file in sourceDir, skipping
+// outputDir so that builds into the source tree don't trigger themselves
+func snapshotSource(sourceDir, outputDir string) (map[string]fileState, error) {
+	absOutput, err := filepath.Abs(outputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := make(map[string]fileState)
+	err = filepath.WalkDir(sourceDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			absPath, err := filepath.Abs(path)
+			if err != nil {
+				return err
+			}
+			if absPath == absOutput || strings.HasPrefix(absPath, absOutput+string(filepath.Separator)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		snapshot[path] = fileState{modTime: info.ModTime().UnixNano(), size: info.Size()}
+		return nil
+	})
+	return snapshot, err
+}
@@ -4,4 +4,4 @@ go 1.23.7
 
 require github.com/tdewolff/minify/v2 v2.23.1
 
-require github.com/tdewolff/parse/v2 v2.7.23 // indirect
+require github.com/tdewolff/parse/v2 v2.7.23
@@ -0,0 +1,331 @@
+// Package htmlrewrite rewrites the asset references in HTML pages to point at
+// fingerprinted files, for builds and for loaders fingerprinting at startup.
+package htmlrewrite
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/parse/v2/html"
+)
+
+// IsPage reports whether a source file is an HTML page, which is emitted
+// under its original name with its asset references rewritten
+func IsPage(relPath string) bool {
+	ext := strings.ToLower(filepath.Ext(relPath))
+	return ext == ".html" || ext == ".htm"
+}
+
+// htmlAttr is an attribute of a start tag as it appears in the page
+type htmlAttr struct {
+	key string
+	// raw is the attribute's source, including the whitespace before it
+	raw []byte
+	// val is the raw value, including quotes
+	val []byte
+}
+
+// value returns the attribute value without quotes
+func (a htmlAttr) value() string {
+	return string(parse.TrimWhitespace(bytes.Trim(a.val, `"'`)))
+}
+
+// withValue returns the attribute's source with its value replaced, keeping its quotes
+func (a htmlAttr) withValue(value string) []byte {
+	prefix := a.raw[:len(a.raw)-len(a.val)]
+	quote := ""
+	if len(a.val) > 0 && (a.val[0] == '"' || a.val[0] == '\'') {
+		quote = string(a.val[0])
+	}
+	return []byte(string(prefix) + quote + value + quote)
+}
+
+// Warning is a reference from a page to a local file missing from the manifest
+type Warning struct {
+	Page    string
+	Message string
+}
+
+// Rewriter rewrites asset references in HTML pages
+type Rewriter struct {
+	// Assets maps the original names of assets to their fingerprinted names
+	Assets map[string]string
+	// Integrity maps the original names of assets to their integrity values
+	Integrity map[string]string
+	// AddIntegrity adds integrity and crossorigin attributes to rewritten scripts and stylesheets
+	AddIntegrity bool
+	// Warnings lists the references to local files missing from the manifest
+	Warnings []Warning
+}
+
+// Rewrite returns page with the src, href and srcset attributes of script,
+// link, img and source elements pointing at fingerprinted files. page is the
+// page's path relative to the source directory; references are resolved
+// relative to it, or to the source directory if they start with /. The page
+// is otherwise copied byte for byte.
+func (r *Rewriter) Rewrite(page string, content []byte) ([]byte, error) {
+	var out bytes.Buffer
+	// the lexer lowercases tag and attribute names in place, so it reads a
+	// copy, used only to locate tokens, and the output is copied from content
+	lexed := bytes.Clone(content)
+	lexer := html.NewLexer(parse.NewInputBytes(lexed))
+	pos := 0
+
+	// copyToken writes the source up to and including a token; the lexer drops
+	// whitespace before the end of a start tag, which is recovered from content
+	copyToken := func(data []byte) {
+		end := pos + bytes.Index(lexed[pos:], data) + len(data)
+		out.Write(content[pos:end])
+		pos = end
+	}
+
+	for {
+		tt, data := lexer.Next()
+		switch tt {
+		case html.ErrorToken:
+			if err := lexer.Err(); err != io.EOF {
+				return nil, fmt.Errorf("failed to parse %s: %w", page, err)
+			}
+			out.Write(content[pos:])
+			return out.Bytes(), nil
+
+		case html.StartTagToken:
+			copyToken(data)
+			tag := string(lexer.Text())
+
+			var attrs []htmlAttr
+			for {
+				tt, data := lexer.Next()
+				if tt != html.AttributeToken {
+					// rewritten attributes are written before the whitespace preceding the tag end
+					out.Write(r.rewriteTag(page, tag, attrs))
+					if tt == html.ErrorToken {
+						if err := lexer.Err(); err != io.EOF {
+							return nil, fmt.Errorf("failed to parse %s: %w", page, err)
+						}
+						out.Write(content[pos:])
+						return out.Bytes(), nil
+					}
+					copyToken(data)
+					break
+				}
+
+				start := pos + bytes.Index(lexed[pos:], data)
+				end := start + len(data)
+				attr := htmlAttr{key: string(lexer.AttrKey()), raw: content[pos:end]}
+				if !lexer.HasTemplate() && lexer.AttrVal() != nil {
+					attr.val = content[end-len(lexer.AttrVal()) : end]
+				}
+				attrs = append(attrs, attr)
+				pos = end
+			}
+
+		default:
+			copyToken(data)
+		}
+	}
+}
+
+// rewriteTag returns the attributes of a start tag with references to assets
+// rewritten, adding integrity attributes if enabled. An existing integrity
+// attribute is replaced if enabled, and reported as stale otherwise, as it was
+// computed for the file before it was built.
+func (r *Rewriter) rewriteTag(page, tag string, attrs []htmlAttr) []byte {
+	var urlAttrs []string
+	switch tag {
+	case "script":
+		urlAttrs = []string{"src"}
+	case "link":
+		urlAttrs = []string{"href"}
+	case "img", "source":
+		urlAttrs = []string{"src", "srcset"}
+	}
+
+	// the rewritten attributes are joined once the referenced asset is known
+	rewritten := make([][]byte, len(attrs))
+	var referenced string
+	integrityAttr := -1
+	has := make(map[string]bool)
+	for i, attr := range attrs {
+		has[attr.key] = true
+		rewritten[i] = attr.raw
+		if attr.key == "integrity" && attr.val != nil {
+			integrityAttr = i
+		}
+		if attr.val == nil || !slices.Contains(urlAttrs, attr.key) {
+			continue
+		}
+
+		if attr.key == "srcset" {
+			rewritten[i] = attr.withValue(r.rewriteSrcset(page, attr.value()))
+			continue
+		}
+
+		ref, name, ok := r.resolve(page, attr.value())
+		if !ok {
+			if name != "" && (tag != "link" || r.wantsIntegrity(tag, attrs)) {
+				r.warnMissing(page, name)
+			}
+			continue
+		}
+		referenced = name
+		rewritten[i] = attr.withValue(ref)
+	}
+
+	var out bytes.Buffer
+	integrity, hasIntegrity := r.Integrity[referenced]
+	addIntegrity := r.AddIntegrity && referenced != "" && hasIntegrity && r.wantsIntegrity(tag, attrs)
+	if integrityAttr >= 0 && referenced != "" && r.wantsIntegrity(tag, attrs) {
+		if addIntegrity {
+			rewritten[integrityAttr] = attrs[integrityAttr].withValue(integrity)
+		} else {
+			r.Warnings = append(r.Warnings, Warning{Page: page, Message: fmt.Sprintf("has an integrity attribute for %s that does not match its built file", filepath.ToSlash(referenced))})
+		}
+	}
+	for _, raw := range rewritten {
+		out.Write(raw)
+	}
+
+	if addIntegrity && !has["integrity"] {
+		fmt.Fprintf(&out, ` integrity="%s"`, integrity)
+		if !has["crossorigin"] {
+			out.WriteString(` crossorigin="anonymous"`)
+		}
+	}
+	return out.Bytes()
+}
+
+// wantsIntegrity reports whether the browser checks integrity for a tag:
+// scripts and stylesheet, preload and modulepreload links
+func (r *Rewriter) wantsIntegrity(tag string, attrs []htmlAttr) bool {
+	if tag == "script" {
+		return true
+	}
+	if tag != "link" {
+		return false
+	}
+	for _, attr := range attrs {
+		if attr.key != "rel" {
+			continue
+		}
+		for _, rel := range strings.Fields(strings.ToLower(attr.value())) {
+			if rel == "stylesheet" || rel == "preload" || rel == "modulepreload" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rewriteSrcset rewrites every URL in a srcset attribute, keeping its
+// descriptors and separators. Candidates are parsed as in the HTML spec: the
+// URL runs up to whitespace, so it may contain commas as data: URLs do, and
+// its descriptors run up to a comma outside parentheses.
+func (r *Rewriter) rewriteSrcset(page, srcset string) string {
+	var out strings.Builder
+	pos := 0
+	for pos < len(srcset) {
+		start := pos
+		for pos < len(srcset) && (isHTMLSpace(srcset[pos]) || srcset[pos] == ',') {
+			pos++
+		}
+		out.WriteString(srcset[start:pos])
+		if pos == len(srcset) {
+			break
+		}
+
+		// trailing commas end a URL without descriptors
+		start = pos
+		for pos < len(srcset) && !isHTMLSpace(srcset[pos]) {
+			pos++
+		}
+		end := pos
+		for end > start && srcset[end-1] == ',' {
+			end--
+		}
+		url := srcset[start:end]
+		if ref, name, ok := r.resolve(page, url); ok {
+			url = ref
+		} else if name != "" {
+			r.warnMissing(page, name)
+		}
+		out.WriteString(url)
+		if end < pos {
+			out.WriteString(srcset[end:pos])
+			continue
+		}
+
+		start = pos
+		inParens := false
+		for ; pos < len(srcset); pos++ {
+			c := srcset[pos]
+			if c == ',' && !inParens {
+				break
+			}
+			if c == '(' {
+				inParens = true
+			} else if c == ')' {
+				inParens = false
+			}
+		}
+		out.WriteString(srcset[start:pos])
+	}
+	return out.String()
+}
+
+// isHTMLSpace reports whether c is ASCII whitespace as HTML defines it
+func isHTMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\f' || c == '\r'
+}
+
+// warnMissing records a reference from a page to a file missing from the build
+func (r *Rewriter) warnMissing(page, name string) {
+	r.Warnings = append(r.Warnings, Warning{Page: page, Message: fmt.Sprintf("references %s, which is not in the build", filepath.ToSlash(name))})
+}
+
+// resolve looks up a reference from a page in the manifest. It returns the
+// reference with its file name fingerprinted, keeping its directory, query and
+// fragment, and the asset it refers to. For a local file missing from the
+// manifest, it returns the file's name with ok false.
+func (r *Rewriter) resolve(page, ref string) (string, string, bool) {
+	if ref == "" || strings.HasPrefix(ref, "//") || strings.Contains(ref, ":") {
+		// empty, protocol-relative or absolute URLs, including data: URLs
+		return "", "", false
+	}
+
+	refPath, suffix := ref, ""
+	if i := strings.IndexAny(ref, "?#"); i >= 0 {
+		refPath, suffix = ref[:i], ref[i:]
+	}
+	if refPath == "" || strings.HasSuffix(refPath, "/") {
+		return "", "", false
+	}
+
+	var name string
+	if strings.HasPrefix(refPath, "/") {
+		name = path.Clean(strings.TrimPrefix(refPath, "/"))
+	} else {
+		name = path.Join(path.Dir(filepath.ToSlash(page)), refPath)
+	}
+	if strings.HasPrefix(name, "../") {
+		return "", "", false
+	}
+
+	name = filepath.FromSlash(name)
+	if IsPage(name) {
+		return "", "", false
+	}
+	fingerprinted, ok := r.Assets[name]
+	if !ok {
+		return "", name, false
+	}
+
+	dir := refPath[:strings.LastIndexByte(refPath, '/')+1]
+	return dir + path.Base(filepath.ToSlash(fingerprinted)) + suffix, name, true
+}
@@ -0,0 +1,122 @@
+package htmlrewrite
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRewriter(t *testing.T) {
+	assets := map[string]string{
+		"app.js":          "app-1111111111111111.js",
+		"css/site.css":    "css/site-2222222222222222.css",
+		"img/logo.png":    "img/logo-3333333333333333.png",
+		"img/logo@2x.png": "img/logo@2x-4444444444444444.png",
+		"about.html":      "about.html",
+	}
+	integrity := map[string]string{
+		"app.js":       "sha384-app",
+		"css/site.css": "sha384-site",
+	}
+
+	tests := []struct {
+		name      string
+		page      string
+		html      string
+		integrity bool
+		want      string
+		warnings  []Warning
+	}{
+		{
+			name: "script and stylesheet",
+			page: "index.html",
+			html: `<link rel="stylesheet" href="css/site.css"><script src='app.js' defer></script>`,
+			want: `<link rel="stylesheet" href="css/site-2222222222222222.css"><script src='app-1111111111111111.js' defer></script>`,
+		},
+		{
+			name: "root-relative and parent references",
+			page: "blog/post.html",
+			html: `<img src="/img/logo.png"><img src="../img/logo.png" alt="Logo">`,
+			want: `<img src="/img/logo-3333333333333333.png"><img src="../img/logo-3333333333333333.png" alt="Logo">`,
+		},
+		{
+			name: "srcset and source",
+			page: "index.html",
+			html: `<picture><source srcset="img/logo.png 1x, img/logo@2x.png 2x"><img src=img/logo.png srcset="img/logo@2x.png 2x"></picture>`,
+			want: `<picture><source srcset="img/logo-3333333333333333.png 1x, img/logo@2x-4444444444444444.png 2x"><img src=img/logo-3333333333333333.png srcset="img/logo@2x-4444444444444444.png 2x"></picture>`,
+		},
+		{
+			name: "srcset with a data URL",
+			page: "index.html",
+			html: `<img srcset="data:image/png;base64,iVBORw0KGgo=,AAAA 1x,img/logo@2x.png 2x">`,
+			want: `<img srcset="data:image/png;base64,iVBORw0KGgo=,AAAA 1x,img/logo@2x-4444444444444444.png 2x">`,
+		},
+		{
+			name: "srcset without descriptors or with parentheses",
+			page: "index.html",
+			html: "<img srcset=\"img/logo.png,\n  img/logo@2x.png 2x (future, descriptor), app.js\">",
+			want: "<img srcset=\"img/logo-3333333333333333.png,\n  img/logo@2x-4444444444444444.png 2x (future, descriptor), app-1111111111111111.js\">",
+		},
+		{
+			name: "query and fragment are kept",
+			page: "index.html",
+			html: `<script src="app.js?v=1#main"></script>`,
+			want: `<script src="app-1111111111111111.js?v=1#main"></script>`,
+		},
+		{
+			name:     "unknown, external and page references are untouched",
+			page:     "index.html",
+			html:     `<script src="https://cdn.example.com/app.js"></script><img src="data:image/png;base64,AA=="><link href="missing.css" rel="stylesheet"><a href="about.html">About</a><link rel="alternate" href="about.html">`,
+			want:     `<script src="https://cdn.example.com/app.js"></script><img src="data:image/png;base64,AA=="><link href="missing.css" rel="stylesheet"><a href="about.html">About</a><link rel="alternate" href="about.html">`,
+			warnings: []Warning{{Page: "index.html", Message: "references missing.css, which is not in the build"}},
+		},
+		{
+			name: "other markup is copied byte for byte",
+			page: "index.html",
+			html: "<!DOCTYPE html>\n<!-- app.js -->\n<IMG  SRC = \"img/logo.png\"  >\n<p>app.js</p>\n<script>load('app.js')</script>\n<br />",
+			want: "<!DOCTYPE html>\n<!-- app.js -->\n<IMG  SRC = \"img/logo-3333333333333333.png\"  >\n<p>app.js</p>\n<script>load('app.js')</script>\n<br />",
+		},
+		{
+			name:      "integrity",
+			page:      "index.html",
+			html:      `<link rel="stylesheet" href="css/site.css" ><script type="module" src="app.js"></script><img src="img/logo.png">`,
+			integrity: true,
+			want:      `<link rel="stylesheet" href="css/site-2222222222222222.css" integrity="sha384-site" crossorigin="anonymous" ><script type="module" src="app-1111111111111111.js" integrity="sha384-app" crossorigin="anonymous"></script><img src="img/logo-3333333333333333.png">`,
+		},
+		{
+			name:      "existing integrity is replaced and crossorigin kept",
+			page:      "index.html",
+			html:      `<script src="app.js" crossorigin="use-credentials"></script><script integrity='sha384-pinned' src="app.js"></script>`,
+			integrity: true,
+			want:      `<script src="app-1111111111111111.js" crossorigin="use-credentials" integrity="sha384-app"></script><script integrity='sha384-app' src="app-1111111111111111.js"></script>`,
+		},
+		{
+			name:     "existing integrity is reported without integrity",
+			page:     "blog/post.html",
+			html:     `<link rel="stylesheet" href="../css/site.css" integrity="sha384-pinned"><img src="/img/logo.png" integrity="sha384-pinned">`,
+			want:     `<link rel="stylesheet" href="../css/site-2222222222222222.css" integrity="sha384-pinned"><img src="/img/logo-3333333333333333.png" integrity="sha384-pinned">`,
+			warnings: []Warning{{Page: "blog/post.html", Message: "has an integrity attribute for css/site.css that does not match its built file"}},
+		},
+		{
+			name: "unterminated tag",
+			page: "index.html",
+			html: `<p>hi</p><script src="app.js"`,
+			want: `<p>hi</p><script src="app-1111111111111111.js"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rewriter := &Rewriter{Assets: assets, Integrity: integrity, AddIntegrity: tt.integrity}
+			got, err := rewriter.Rewrite(tt.page, []byte(tt.html))
+			if err != nil {
+				t.Fatalf("Rewrite failed: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Rewrite() =\n%s\nwant\n%s", got, tt.want)
+			}
+			if !slices.Equal(rewriter.Warnings, tt.warnings) {
+				t.Errorf("Warnings = %v, want %v", rewriter.Warnings, tt.warnings)
+			}
+		})
+	}
+}
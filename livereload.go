@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/jm96441n/assetid/assetid"
+)
+
+// liveReloadEventsPath is the Server-Sent Events endpoint the live reload script listens on
+const liveReloadEventsPath = "/__assetid/events"
+
+// Live reload event names understood by liveReloadScript
+const (
+	eventReload     = "reload"
+	eventCSS        = "css"
+	eventBuildError = "build-error"
+)
+
+// liveReloadScript reloads stylesheets in place on CSS-only changes, reloads the
+// page on anything else or when it does not link to a changed stylesheet, and
+// shows build errors in an overlay
+const liveReloadScript = `(function () {
+  var script = document.currentScript;
+  var source = new EventSource(new URL("` + liveReloadEventsPath + `", script ? script.src : location.href));
+  var overlay;
+
+  function hideOverlay() {
+    if (overlay) {
+      overlay.remove();
+      overlay = null;
+    }
+  }
+
+  function showOverlay(message) {
+    hideOverlay();
+    overlay = document.createElement("div");
+    overlay.setAttribute("style", "position:fixed;inset:0;z-index:2147483647;overflow:auto;padding:2em;" +
+      "background:rgba(20,20,20,.92);color:#ff8a80;font:14px/1.5 monospace;white-space:pre-wrap");
+    var title = document.createElement("strong");
+    title.textContent = "assetid build failed\n\n";
+    overlay.appendChild(title);
+    overlay.appendChild(document.createTextNode(message));
+    document.body.appendChild(overlay);
+  }
+
+  source.addEventListener("` + eventReload + `", function () {
+    location.reload();
+  });
+
+  // outputPath returns a /dist/ path relative to the output directory, with a
+  // leading slash, as pages may link to it under another prefix
+  function outputPath(distPath) {
+    return distPath.slice("/dist".length);
+  }
+
+  source.addEventListener("` + eventCSS + `", function (event) {
+    hideOverlay();
+    var changes = JSON.parse(event.data);
+    var links = document.querySelectorAll('link[rel="stylesheet"]');
+    var swapped = changes.every(function (change) {
+      var found = false;
+      links.forEach(function (link) {
+        var url = new URL(link.href, location.href);
+        var from = [change.old, change.unhashed].map(outputPath).find(function (name) {
+          return url.pathname.endsWith(name);
+        });
+        if (from === undefined) {
+          return;
+        }
+        found = true;
+        if (change.integrity) {
+          link.integrity = change.integrity;
+        } else {
+          link.removeAttribute("integrity");
+        }
+        url.pathname = url.pathname.slice(0, url.pathname.length - from.length) + outputPath(change.new);
+        link.href = url.href;
+      });
+      return found;
+    });
+    // stylesheets the page does not link to, e.g. imported ones, need a reload
+    if (!swapped) {
+      location.reload();
+    }
+  });
+
+  source.addEventListener("` + eventBuildError + `", function (event) {
+    showOverlay(JSON.parse(event.data).message);
+  });
+})();
+`
+
+// liveReloadEvent is one Server-Sent Event sent to connected pages
+type liveReloadEvent struct {
+	name string
+	data string
+}
+
+// cssChange describes a stylesheet to swap in place, by URL path
+type cssChange struct {
+	Unhashed  string `json:"unhashed"`
+	Old       string `json:"old"`
+	New       string `json:"new"`
+	Integrity string `json:"integrity,omitempty"`
+}
+
+// reloadBroker fans live reload events out to every connected page
+type reloadBroker struct {
+	mu      sync.Mutex
+	clients map[chan liveReloadEvent]struct{}
+	// lastError is replayed to pages that connect while the build is broken
+	lastError *liveReloadEvent
+}
+
+func newReloadBroker() *reloadBroker {
+	return &reloadBroker{clients: make(map[chan liveReloadEvent]struct{})}
+}
+
+// subscribe registers a new page, returning its event channel and a function to unregister it
+func (b *reloadBroker) subscribe() (<-chan liveReloadEvent, func()) {
+	events := make(chan liveReloadEvent, 8)
+
+	b.mu.Lock()
+	b.clients[events] = struct{}{}
+	if b.lastError != nil {
+		events <- *b.lastError
+	}
+	b.mu.Unlock()
+
+	return events, func() {
+		b.mu.Lock()
+		delete(b.clients, events)
+		b.mu.Unlock()
+	}
+}
+
+// publish sends an event to every connected page, dropping it for pages that are not keeping up
+func (b *reloadBroker) publish(event liveReloadEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if event.name == eventBuildError {
+		b.lastError = &event
+	} else {
+		b.lastError = nil
+	}
+
+	for client := range b.clients {
+		select {
+		case client <- event:
+		default:
+		}
+	}
+}
+
+// publishBuild tells connected pages about the result of a build, given the
+// manifests before and after it
+func (b *reloadBroker) publishBuild(oldManifest, newManifest AssetManifest, buildErr error) {
+	if buildErr != nil {
+		data, _ := json.Marshal(map[string]string{"message": buildErr.Error()})
+		b.publish(liveReloadEvent{name: eventBuildError, data: string(data)})
+		return
+	}
+
+	diff := assetid.DiffManifests(oldManifest, newManifest)
+	changes, cssOnly := cssChanges(diff, newManifest)
+	if !cssOnly || len(changes) == 0 {
+		b.publish(liveReloadEvent{name: eventReload, data: "{}"})
+		return
+	}
+
+	data, _ := json.Marshal(changes)
+	b.publish(liveReloadEvent{name: eventCSS, data: string(data)})
+}
+
+// cssChanges returns the stylesheet swaps for a diff, and whether every change
+// in the diff is a stylesheet that can be swapped without reloading the page
+func cssChanges(diff *assetid.ManifestDiff, manifest AssetManifest) ([]cssChange, bool) {
+	if len(diff.Added) > 0 || len(diff.Removed) > 0 || len(diff.Renamed) > 0 {
+		return nil, false
+	}
+
+	changes := make([]cssChange, 0, len(diff.Changed))
+	for _, entry := range diff.Changed {
+		if path.Ext(entry.Asset) != ".css" {
+			return nil, false
+		}
+		changes = append(changes, cssChange{
+			Unhashed:  path.Join("/dist", entry.Asset),
+			Old:       path.Join("/dist", entry.Old),
+			New:       path.Join("/dist", entry.New),
+			Integrity: manifest.Integrity[entry.Asset],
+		})
+	}
+	return changes, true
+}
+
+// ServeHTTP streams live reload events to a page until it disconnects
+func (b *reloadBroker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	events, unsubscribe := b.subscribe()
+	defer unsubscribe()
+
+	keepAlive := time.NewTicker(15 * time.Second)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case event := <-events:
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.name, event.data)
+		}
+		flusher.Flush()
+	}
+}
+
+// serveLiveReloadScript serves liveReloadScript
+func serveLiveReloadScript(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	fmt.Fprint(w, liveReloadScript)
+}
+
+// injectLiveReload adds the live reload script to an HTML page, before
+// </body> when there is one and at the end otherwise
+func injectLiveReload(page []byte) []byte {
+	tag := []byte(`<script src="` + assetid.LiveReloadPath + `"></script>`)
+
+	i := bytes.LastIndex(bytes.ToLower(page), []byte("</body>"))
+	if i < 0 {
+		return append(page, tag...)
+	}
+
+	injected := make([]byte, 0, len(page)+len(tag))
+	injected = append(injected, page[:i]...)
+	injected = append(injected, tag...)
+	return append(injected, page[i:]...)
+}
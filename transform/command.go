@@ -0,0 +1,147 @@
+package transform
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileArg is replaced in the arguments of a Command with FileInput by the
+// path of a temporary file holding the input
+const FileArg = "{file}"
+
+// Command is a transformer running an external program, such as sass or an
+// image optimizer, on each file. The program reads the file from stdin, or
+// from a temporary file with FileInput, and writes the result to stdout.
+type Command struct {
+	// Args is the program and its arguments
+	Args []string
+	// FileInput passes the file as a temporary file named by the FileArg
+	// argument instead of on stdin, for programs that cannot read stdin
+	FileInput bool
+	// Ext replaces the extension of the file, e.g. .css for a sass command
+	Ext string
+	// Dir is the working directory of the program, usually the source directory
+	Dir string
+	// Env holds KEY=value variables added to the program's environment
+	Env []string
+	// ClearEnv starts the program with only the variables in Env
+	ClearEnv bool
+	// Timeout stops the program if it runs longer; 0 disables it
+	Timeout time.Duration
+	// Concurrency is the number of files the program runs on at once; 0 uses the number of CPUs
+	Concurrency int
+
+	once  sync.Once
+	slots chan struct{}
+}
+
+// Transform runs the program on file. The program also gets the file's path
+// relative to the source directory in ASSETID_PATH.
+func (c *Command) Transform(ctx context.Context, file *File) (*Result, error) {
+	if len(c.Args) == 0 {
+		return nil, errors.New("command has no program")
+	}
+
+	c.once.Do(func() {
+		concurrency := c.Concurrency
+		if concurrency <= 0 {
+			concurrency = runtime.NumCPU()
+		}
+		c.slots = make(chan struct{}, concurrency)
+	})
+	select {
+	case c.slots <- struct{}{}:
+		defer func() { <-c.slots }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	// parent is done when the caller cancels, rather than the timeout expiring
+	parent := ctx
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	args := c.Args
+	var stdin []byte
+	if c.FileInput {
+		input, err := writeTempInput(file)
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(input)
+
+		args = make([]string, len(c.Args))
+		for i, arg := range c.Args {
+			args[i] = strings.ReplaceAll(arg, FileArg, input)
+		}
+	} else {
+		stdin = file.Data
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = c.Dir
+	cmd.Env = c.environ(file)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		// the context's error replaces the signal that killed the program, so
+		// callers can tell an interrupted build from a failing command
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = ctxErr
+			if parent.Err() == nil && c.Timeout > 0 {
+				err = fmt.Errorf("timed out after %s: %w", c.Timeout, ctxErr)
+			}
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("failed to run %s: %w: %s", c.Args[0], err, msg)
+		}
+		return nil, fmt.Errorf("failed to run %s: %w", c.Args[0], err)
+	}
+
+	result := &Result{Data: stdout.Bytes()}
+	if c.Ext != "" {
+		result.Path = strings.TrimSuffix(file.Path, path.Ext(file.Path)) + c.Ext
+	}
+	return result, nil
+}
+
+// environ returns the environment of the program run on file
+func (c *Command) environ(file *File) []string {
+	var env []string
+	if !c.ClearEnv {
+		env = os.Environ()
+	}
+	env = append(env, c.Env...)
+	return append(env, "ASSETID_PATH="+file.Path)
+}
+
+// writeTempInput writes the content of file to a temporary file with the
+// same extension, so programs detecting the input type by name still work
+func writeTempInput(file *File) (string, error) {
+	temp, err := os.CreateTemp("", "assetid-*"+path.Ext(file.Path))
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary input: %w", err)
+	}
+	defer temp.Close()
+
+	if _, err := temp.Write(file.Data); err != nil {
+		os.Remove(temp.Name())
+		return "", fmt.Errorf("failed to write temporary input: %w", err)
+	}
+	return temp.Name(), nil
+}
@@ -0,0 +1,148 @@
+package transform
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  *Command
+		wantData string
+		wantPath string
+	}{
+		{
+			name:     "stdin",
+			command:  &Command{Args: []string{"tr", "a-z", "A-Z"}},
+			wantData: "BODY { COLOR: RED; }",
+		},
+		{
+			name:     "file input",
+			command:  &Command{Args: []string{"sh", "-c", `case "$1" in *.scss) cat "$1";; esac`, "sh", FileArg}, FileInput: true},
+			wantData: "body { color: red; }",
+		},
+		{
+			name:     "new extension",
+			command:  &Command{Args: []string{"cat"}, Ext: ".css"},
+			wantData: "body { color: red; }",
+			wantPath: "styles/app.css",
+		},
+		{
+			name:     "environment",
+			command:  &Command{Args: []string{"sh", "-c", `printf '%s %s' "$MODE" "$ASSETID_PATH"`}, Env: []string{"MODE=production"}},
+			wantData: "production styles/app.scss",
+		},
+		{
+			name:     "cleared environment",
+			command:  &Command{Args: []string{"/bin/sh", "-c", `printf '%s|%s' "$HOME" "$MODE"`}, Env: []string{"MODE=production"}, ClearEnv: true},
+			wantData: "|production",
+		},
+		{
+			name:     "working directory",
+			command:  &Command{Args: []string{"pwd"}, Dir: "/"},
+			wantData: "/\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := &File{Path: "styles/app.scss", Data: []byte("body { color: red; }")}
+			result, err := tt.command.Transform(context.Background(), file)
+			if err != nil {
+				t.Fatalf("Transform failed: %v", err)
+			}
+			if string(result.Data) != tt.wantData {
+				t.Errorf("Data = %q, want %q", result.Data, tt.wantData)
+			}
+			if result.Path != tt.wantPath {
+				t.Errorf("Path = %q, want %q", result.Path, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestCommandErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		command *Command
+		wantErr string
+	}{
+		{
+			name:    "stderr included",
+			command: &Command{Args: []string{"sh", "-c", "echo 'Error: expected \"}\"' >&2; exit 65"}},
+			wantErr: `failed to run sh: exit status 65: Error: expected "}"`,
+		},
+		{
+			name:    "timeout",
+			command: &Command{Args: []string{"sleep", "5"}, Timeout: 50 * time.Millisecond},
+			wantErr: "failed to run sleep: timed out after 50ms",
+		},
+		{
+			name:    "missing program",
+			command: &Command{Args: []string{"assetid-missing-program"}},
+			wantErr: "failed to run assetid-missing-program:",
+		},
+		{
+			name:    "no program",
+			command: &Command{},
+			wantErr: "command has no program",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.command.Transform(context.Background(), &File{Path: "app.scss"})
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("Transform error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCommandCanceled(t *testing.T) {
+	tests := []struct {
+		name    string
+		command *Command
+		cancel  func(context.CancelFunc)
+	}{
+		{name: "before start", command: &Command{Args: []string{"sleep", "5"}}, cancel: func(cancel context.CancelFunc) { cancel() }},
+		{name: "while running", command: &Command{Args: []string{"sleep", "5"}, Timeout: time.Minute}, cancel: func(cancel context.CancelFunc) {
+			time.AfterFunc(50*time.Millisecond, cancel)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			tt.cancel(cancel)
+
+			_, err := tt.command.Transform(ctx, &File{Path: "app.scss"})
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("Transform error = %v, want it to wrap %v", err, context.Canceled)
+			}
+		})
+	}
+}
+
+func TestCommandConcurrency(t *testing.T) {
+	command := &Command{Args: []string{"cat"}, Concurrency: 1}
+	command.once.Do(func() { command.slots = make(chan struct{}, 1) })
+	command.slots <- struct{}{}
+
+	// the only slot is taken, so the command waits until the context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := command.Transform(ctx, &File{Path: "app.js"}); err != context.DeadlineExceeded {
+		t.Errorf("Transform error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	<-command.slots
+	if _, err := command.Transform(context.Background(), &File{Path: "app.js"}); err != nil {
+		t.Errorf("Transform failed once a slot was free: %v", err)
+	}
+}
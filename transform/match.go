@@ -0,0 +1,117 @@
+package transform
+
+import (
+	"fmt"
+	"mime"
+	"path"
+	"strings"
+)
+
+// Matcher selects the files a transformer applies to
+type Matcher interface {
+	Match(filePath, mediaType string) bool
+}
+
+// MatcherFunc adapts a function to the Matcher interface
+type MatcherFunc func(filePath, mediaType string) bool
+
+// Match calls f
+func (f MatcherFunc) Match(filePath, mediaType string) bool {
+	return f(filePath, mediaType)
+}
+
+// All matches every file
+func All() Matcher {
+	return MatcherFunc(func(string, string) bool { return true })
+}
+
+// Glob matches files whose slash-separated path matches a pattern. Patterns
+// use path.Match syntax within a segment, and a ** segment matches any number
+// of directories, e.g. vendor/**/*.js matches vendor/a.js and vendor/b/c/d.js.
+func Glob(pattern string) (Matcher, error) {
+	for _, segment := range strings.Split(pattern, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+		}
+	}
+
+	segments := strings.Split(pattern, "/")
+	return MatcherFunc(func(filePath, _ string) bool {
+		return matchSegments(segments, strings.Split(filePath, "/"))
+	}), nil
+}
+
+// MustGlob is like Glob but panics if the pattern is invalid
+func MustGlob(pattern string) Matcher {
+	m, err := Glob(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// matchSegments matches path segments against pattern segments
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// MediaType matches files of the given media types; a type ending in /*, such
+// as image/*, matches every subtype
+func MediaType(types ...string) Matcher {
+	return MatcherFunc(func(_, mediaType string) bool {
+		for _, t := range types {
+			if prefix, ok := strings.CutSuffix(t, "*"); ok && strings.HasPrefix(mediaType, prefix) {
+				return true
+			}
+			if t == mediaType {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// mediaTypeOverrides fixes the media type of extensions that mime.TypeByExtension
+// does not know, or knows under different names, depending on the system's MIME tables
+var mediaTypeOverrides = map[string]string{
+	".js":          "text/javascript",
+	".mjs":         "text/javascript",
+	".css":         "text/css",
+	".html":        "text/html",
+	".htm":         "text/html",
+	".svg":         "image/svg+xml",
+	".json":        "application/json",
+	".map":         "application/json",
+	".webmanifest": "application/manifest+json",
+	".xml":         "text/xml",
+}
+
+// MediaTypeOf returns the media type of a file from its extension, without
+// parameters, or an empty string if it is unknown
+func MediaTypeOf(filePath string) string {
+	ext := strings.ToLower(path.Ext(filePath))
+	if mediaType, ok := mediaTypeOverrides[ext]; ok {
+		return mediaType
+	}
+
+	mediaType, _, _ := strings.Cut(mime.TypeByExtension(ext), ";")
+	return strings.TrimSpace(mediaType)
+}
@@ -0,0 +1,91 @@
+package transform
+
+import "testing"
+
+func TestGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{pattern: "vendor/legacy/*.js", name: "vendor/legacy/lib.js", want: true},
+		{pattern: "vendor/legacy/*.js", name: "vendor/legacy/sub/lib.js", want: false},
+		{pattern: "vendor/**/*.js", name: "vendor/lib.js", want: true},
+		{pattern: "vendor/**/*.js", name: "vendor/a/b/lib.js", want: true},
+		{pattern: "vendor/**/*.js", name: "src/vendor/lib.js", want: false},
+		{pattern: "**/*.min.js", name: "lib.min.js", want: true},
+		{pattern: "**", name: "a/b/c", want: true},
+		{pattern: "*.js", name: "js/app.js", want: false},
+		{pattern: "img/icon-?.svg", name: "img/icon-a.svg", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			match, err := Glob(tt.pattern)
+			if err != nil {
+				t.Fatalf("Glob(%q) failed: %v", tt.pattern, err)
+			}
+			if got := match.Match(tt.name, ""); got != tt.want {
+				t.Errorf("Glob(%q).Match(%q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGlobErrors(t *testing.T) {
+	if _, err := Glob("vendor/[a.js"); err == nil {
+		t.Error("Expected error for invalid pattern, got nil")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected MustGlob to panic for invalid pattern")
+		}
+	}()
+	MustGlob("[")
+}
+
+func TestMediaType(t *testing.T) {
+	match := MediaType("text/css", "image/*")
+
+	tests := []struct {
+		mediaType string
+		want      bool
+	}{
+		{mediaType: "text/css", want: true},
+		{mediaType: "image/png", want: true},
+		{mediaType: "image/svg+xml", want: true},
+		{mediaType: "text/javascript", want: false},
+		{mediaType: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mediaType, func(t *testing.T) {
+			if got := match.Match("file", tt.mediaType); got != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.mediaType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMediaTypeOf(t *testing.T) {
+	tests := []struct {
+		filePath string
+		want     string
+	}{
+		{filePath: "app.mjs", want: "text/javascript"},
+		{filePath: "INDEX.HTM", want: "text/html"},
+		{filePath: "icon.svg", want: "image/svg+xml"},
+		{filePath: "app.js.map", want: "application/json"},
+		{filePath: "styles.css", want: "text/css"},
+		{filePath: "README", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filePath, func(t *testing.T) {
+			if got := MediaTypeOf(tt.filePath); got != tt.want {
+				t.Errorf("MediaTypeOf(%s) = %q, want %q", tt.filePath, got, tt.want)
+			}
+		})
+	}
+}
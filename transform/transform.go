@@ -0,0 +1,108 @@
+// Package transform defines the per-file processing steps of an assetid build.
+// A Registry chains the transformers matching a file in the order they were
+// added, feeding each one the output of the previous one, before the result
+// is fingerprinted and added to the manifest.
+package transform
+
+import (
+	"context"
+	"fmt"
+)
+
+// File is the input of a transformer
+type File struct {
+	// Path is the file's path relative to the source directory, slash-separated
+	Path string
+	// MediaType is the media type of Path, e.g. text/javascript
+	MediaType string
+	// Data is the file's content as produced by the previous transformer
+	Data []byte
+	// Manifest maps the original names of the assets processed so far to their
+	// fingerprinted names. It must not be modified.
+	Manifest map[string]string
+}
+
+// Result is the output of a transformer
+type Result struct {
+	// Data is the new content of the file
+	Data []byte
+	// Path is the new path of the file if the transformer changed its type,
+	// e.g. app.css for app.scss; empty keeps the path. The result of a
+	// Registry always holds the final path.
+	Path string
+	// Dependencies lists other source files the output was built from, such as
+	// imported partials, relative to the source directory
+	Dependencies []string
+}
+
+// Transformer processes the content of a file
+type Transformer interface {
+	Transform(ctx context.Context, file *File) (*Result, error)
+}
+
+// Func adapts a function to the Transformer interface
+type Func func(ctx context.Context, file *File) (*Result, error)
+
+// Transform calls f
+func (f Func) Transform(ctx context.Context, file *File) (*Result, error) {
+	return f(ctx, file)
+}
+
+// Registry chains transformers, each applied to the files it matches
+type Registry struct {
+	steps []step
+}
+
+// step is a transformer registered for the files a matcher accepts
+type step struct {
+	match       Matcher
+	transformer Transformer
+}
+
+// Add registers a transformer for the files match accepts, after those already registered
+func (r *Registry) Add(match Matcher, transformer Transformer) {
+	r.steps = append(r.steps, step{match: match, transformer: transformer})
+}
+
+// Matches reports whether any transformer runs on a file with the given path
+// and media type
+func (r *Registry) Matches(path, mediaType string) bool {
+	for _, step := range r.steps {
+		if step.match.Match(path, mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// Transform runs every matching transformer on file in order and returns the
+// final content and path with the dependencies reported by all of them. A
+// transformer changing the path of the file also changes its media type, and
+// later transformers are matched against the new ones. A file no transformer
+// matches is returned unchanged. Transform stops with ctx.Err() once ctx is done.
+func (r *Registry) Transform(ctx context.Context, file File) (*Result, error) {
+	source := file.Path
+	result := &Result{Data: file.Data, Path: file.Path}
+	for _, step := range r.steps {
+		if !step.match.Match(file.Path, file.MediaType) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		file.Data = result.Data
+		out, err := step.transformer.Transform(ctx, &file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to transform %s: %w", source, err)
+		}
+		result.Data = out.Data
+		if out.Path != "" && out.Path != file.Path {
+			file.Path = out.Path
+			file.MediaType = MediaTypeOf(out.Path)
+			result.Path = out.Path
+		}
+		result.Dependencies = append(result.Dependencies, out.Dependencies...)
+	}
+	return result, nil
+}
@@ -0,0 +1,173 @@
+package transform
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// appendTransformer appends suffix to the content and reports dependency, if set
+func appendTransformer(suffix, dependency string) Transformer {
+	return Func(func(_ context.Context, file *File) (*Result, error) {
+		result := &Result{Data: append(bytes.Clone(file.Data), suffix...)}
+		if dependency != "" {
+			result.Dependencies = []string{dependency}
+		}
+		return result, nil
+	})
+}
+
+func TestRegistry(t *testing.T) {
+	registry := &Registry{}
+	registry.Add(MediaType("text/css"), appendTransformer("-css", "partials/_base.scss"))
+	registry.Add(All(), appendTransformer("-all", ""))
+	registry.Add(MustGlob("vendor/**"), appendTransformer("-vendor", "vendor/lib.js"))
+
+	tests := []struct {
+		name     string
+		file     File
+		wantData string
+		wantDeps []string
+	}{
+		{
+			name:     "steps chained in order",
+			file:     File{Path: "css/app.css", MediaType: "text/css", Data: []byte("a")},
+			wantData: "a-css-all",
+			wantDeps: []string{"partials/_base.scss"},
+		},
+		{
+			name:     "dependencies accumulated",
+			file:     File{Path: "vendor/lib.css", MediaType: "text/css", Data: []byte("a")},
+			wantData: "a-css-all-vendor",
+			wantDeps: []string{"partials/_base.scss", "vendor/lib.js"},
+		},
+		{
+			name:     "unmatched steps skipped",
+			file:     File{Path: "js/app.js", MediaType: "text/javascript", Data: []byte("a")},
+			wantData: "a-all",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := registry.Transform(context.Background(), tt.file)
+			if err != nil {
+				t.Fatalf("Transform failed: %v", err)
+			}
+			if string(result.Data) != tt.wantData {
+				t.Errorf("Data = %q, want %q", result.Data, tt.wantData)
+			}
+			if !slices.Equal(result.Dependencies, tt.wantDeps) {
+				t.Errorf("Dependencies = %v, want %v", result.Dependencies, tt.wantDeps)
+			}
+		})
+	}
+}
+
+func TestRegistryPathChange(t *testing.T) {
+	registry := &Registry{}
+	registry.Add(MustGlob("**/*.scss"), Func(func(_ context.Context, file *File) (*Result, error) {
+		return &Result{Data: file.Data, Path: "styles/app.css"}, nil
+	}))
+	registry.Add(MediaType("text/css"), appendTransformer("-css", ""))
+	registry.Add(MustGlob("**/*.scss"), appendTransformer("-scss", ""))
+
+	result, err := registry.Transform(context.Background(), File{Path: "styles/app.scss", Data: []byte("a")})
+	if err != nil {
+		t.Fatalf("Transform failed: %v", err)
+	}
+	if string(result.Data) != "a-css" {
+		t.Errorf("Data = %q, want later steps matched against the new path", result.Data)
+	}
+	if result.Path != "styles/app.css" {
+		t.Errorf("Path = %q, want %q", result.Path, "styles/app.css")
+	}
+}
+
+func TestRegistryUnmatched(t *testing.T) {
+	registry := &Registry{}
+	registry.Add(MediaType("text/css"), appendTransformer("-css", ""))
+
+	result, err := registry.Transform(context.Background(), File{Path: "img/logo.png", MediaType: "image/png", Data: []byte("png")})
+	if err != nil {
+		t.Fatalf("Transform failed: %v", err)
+	}
+	if string(result.Data) != "png" {
+		t.Errorf("Data = %q, want unchanged content", result.Data)
+	}
+}
+
+func TestRegistryMatches(t *testing.T) {
+	registry := &Registry{}
+	if registry.Matches("js/app.js", "text/javascript") {
+		t.Error("Expected an empty registry to match nothing")
+	}
+
+	registry.Add(MustGlob("**/*.scss"), appendTransformer("", ""))
+	registry.Add(MediaType("text/css"), appendTransformer("", ""))
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "styles/app.scss", want: true},
+		{path: "styles/site.css", want: true},
+		{path: "js/app.js", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := registry.Matches(tt.path, MediaTypeOf(tt.path)); got != tt.want {
+				t.Errorf("Matches(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistryErrors(t *testing.T) {
+	errBroken := errors.New("broken")
+	var ran bool
+
+	registry := &Registry{}
+	registry.Add(All(), Func(func(context.Context, *File) (*Result, error) {
+		return nil, errBroken
+	}))
+	registry.Add(All(), Func(func(_ context.Context, file *File) (*Result, error) {
+		ran = true
+		return &Result{Data: file.Data}, nil
+	}))
+
+	_, err := registry.Transform(context.Background(), File{Path: "js/app.js", MediaType: "text/javascript"})
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("Transform error = %v, want %v", err, errBroken)
+	}
+	if want := "failed to transform js/app.js: broken"; err.Error() != want {
+		t.Errorf("Transform error = %q, want %q", err, want)
+	}
+	if ran {
+		t.Error("Expected the chain to stop at the failing transformer")
+	}
+}
+
+func TestRegistryCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var ran bool
+
+	registry := &Registry{}
+	registry.Add(All(), Func(func(_ context.Context, file *File) (*Result, error) {
+		cancel()
+		return &Result{Data: file.Data}, nil
+	}))
+	registry.Add(All(), Func(func(_ context.Context, file *File) (*Result, error) {
+		ran = true
+		return &Result{Data: file.Data}, nil
+	}))
+
+	_, err := registry.Transform(ctx, File{Path: "js/app.js", MediaType: "text/javascript"})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Transform error = %v, want %v", err, context.Canceled)
+	}
+	if ran {
+		t.Error("Expected the chain to stop once the context is canceled")
+	}
+}